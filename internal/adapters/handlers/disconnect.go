@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// disconnectPollInterval is how often an in-flight request checks that its client is still there
+const disconnectPollInterval = 100 * time.Millisecond
+
+// ErrClientClosed is the cause of a request context canceled because the client went away
+var ErrClientClosed = errors.New("client closed the connection")
+
+// CancelOnDisconnect cancels the request context once the client closes its
+// connection, so the database work of an abandoned request stops early. The
+// server does not read from a connection while a handler runs, so the socket is
+// peeked at instead, which leaves pipelined requests in place. TLS connections and
+// platforms without a non-blocking peek only stop at the route timeout
+func CancelOnDisconnect(ctx *fiber.Ctx) error {
+	conn, ok := ctx.Context().Conn().(syscall.Conn)
+	if !peekSupported || !ok {
+		return ctx.Next()
+	}
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return ctx.Next()
+	}
+
+	userCtx, cancel := context.WithCancelCause(ctx.UserContext())
+	defer cancel(nil)
+	ctx.SetUserContext(userCtx)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(disconnectPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if peerClosed(raw) {
+					cancel(ErrClientClosed)
+					return
+				}
+			}
+		}
+	}()
+	return ctx.Next()
+}
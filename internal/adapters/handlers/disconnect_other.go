@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd)
+
+package handlers
+
+import "syscall"
+
+const peekSupported = false
+
+func peerClosed(syscall.RawConn) bool { return false }
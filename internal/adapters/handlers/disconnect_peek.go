@@ -0,0 +1,19 @@
+//go:build linux || darwin || freebsd
+
+package handlers
+
+import "syscall"
+
+const peekSupported = true
+
+// peerClosed reports whether the other end closed the connection, without
+// consuming anything it sent
+func peerClosed(raw syscall.RawConn) bool {
+	closed := false
+	err := raw.Control(func(fd uintptr) {
+		var buf [1]byte
+		n, _, err := syscall.Recvfrom(int(fd), buf[:], syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		closed = n == 0 && err == nil
+	})
+	return err == nil && closed
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelOnDisconnect(t *testing.T) {
+	if !peekSupported {
+		t.Skip("no non-blocking peek on this platform")
+	}
+
+	canceled := make(chan error, 1)
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Use(CancelOnDisconnect)
+	app.Get("/slow", func(ctx *fiber.Ctx) error {
+		select {
+		case <-ctx.UserContext().Done():
+			canceled <- context.Cause(ctx.UserContext())
+		case <-time.After(5 * time.Second):
+			canceled <- nil
+		}
+		return nil
+	})
+	app.Get("/wait", func(ctx *fiber.Ctx) error {
+		time.Sleep(3 * disconnectPollInterval)
+		return ctx.SendString(fmt.Sprint(ctx.UserContext().Err()))
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go app.Listener(ln)
+	defer app.Shutdown()
+
+	// A client that stays keeps its request, and its connection for the next one
+	client := &http.Client{}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get("http://" + ln.Addr().String() + "/wait")
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "<nil>", string(body))
+	}
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("GET /slow HTTP/1.1\r\nHost: test\r\n\r\n"))
+	require.NoError(t, err)
+	// Give the handler time to start before going away
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, conn.Close())
+
+	select {
+	case cause := <-canceled:
+		assert.ErrorIs(t, cause, ErrClientClosed)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the request context was not canceled")
+	}
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/utils"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// productETag derives a strong entity tag from the product version
+func productETag(product models.Product) string {
+	return `"` + strconv.FormatInt(product.Version, 10) + `"`
+}
+
+// parseIfMatch extracts the product versions accepted by an If-Match header, a
+// comma separated list of entity tags. An absent header or "*" yields none,
+// meaning any version. If-Match uses the strong comparison, so weak tags and tags
+// that are not ours never match; ok is false when no tag in the header can match
+func parseIfMatch(header string) (expected models.ExpectedVersions, ok bool) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	for header != "" {
+		weak := strings.HasPrefix(header, "W/")
+		header = strings.TrimPrefix(header, "W/")
+		// Entity tags may hold commas, so the list is split on the closing quotes
+		if !strings.HasPrefix(header, `"`) {
+			return nil, false
+		}
+		end := strings.IndexByte(header[1:], '"')
+		if end < 0 {
+			return nil, false
+		}
+		tag := header[1 : end+1]
+		header = strings.TrimLeft(header[end+2:], " \t")
+		if header != "" {
+			if header[0] != ',' {
+				return nil, false
+			}
+			header = strings.TrimLeft(header[1:], " \t")
+		}
+
+		version, err := strconv.ParseInt(tag, 10, 64)
+		if weak || err != nil || version < 1 {
+			continue
+		}
+		expected = append(expected, version)
+	}
+	return expected, len(expected) > 0
+}
+
+// respond writes the service response, tagging single-product payloads with an ETag
+// and errors with the request ID
+func respond(ctx *fiber.Ctx, response utils.ServiceResponse) error {
+	if product, ok := response.Data.(models.Product); ok {
+		ctx.Set(fiber.HeaderETag, productETag(product))
+	}
+	if response.Code >= fiber.StatusBadRequest {
+		response.RequestID = utils.RequestIDFromContext(ctx.UserContext())
+	}
+	return ctx.Status(response.Code).JSON(response)
+}
+
+func preconditionFailed(ctx *fiber.Ctx) error {
+	return respond(ctx, utils.ServiceResponse{
+		Code:    fiber.StatusPreconditionFailed,
+		Message: "If-Match does not match the current product version",
+		Data:    nil,
+	})
+}
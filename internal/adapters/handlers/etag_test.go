@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	for _, tc := range []struct {
+		header   string
+		expected models.ExpectedVersions
+		ok       bool
+	}{
+		{"", nil, true},
+		{" * ", nil, true},
+		{`"3"`, models.ExpectedVersions{3}, true},
+		{`"3", "5",W/"6"`, models.ExpectedVersions{3, 5}, true},
+		{`"a,b", "7"`, models.ExpectedVersions{7}, true},
+		// Strong comparison never matches a weak tag
+		{`W/"3"`, nil, false},
+		{`"0", "abc"`, nil, false},
+		{`"3" "4"`, nil, false},
+		{`3`, nil, false},
+		{`"3`, nil, false},
+	} {
+		expected, ok := parseIfMatch(tc.header)
+		assert.Equal(t, tc.ok, ok, tc.header)
+		assert.Equal(t, tc.expected, expected, tc.header)
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HealthHandler struct {
+	healthService ports.IHealthService
+}
+
+func NewHealthController(healthService ports.IHealthService) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+func (c *HealthHandler) Liveness(ctx *fiber.Ctx) error {
+	response := c.healthService.Liveness(ctx.UserContext())
+	return respond(ctx, response)
+}
+
+func (c *HealthHandler) Readiness(ctx *fiber.Ctx) error {
+	response := c.healthService.Readiness(ctx.UserContext())
+	return respond(ctx, response)
+}
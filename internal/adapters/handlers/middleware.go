@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/utils"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// HeaderActor identifies who made a request; it is recorded in the stock ledger
+const HeaderActor = "X-Actor"
+
+// maxRequestIDLength bounds caller supplied request IDs, which end up in logs and storage
+const maxRequestIDLength = 128
+
+// Actor stores the caller named in the X-Actor header in the request context
+func Actor(ctx *fiber.Ctx) error {
+	if actor := ctx.Get(HeaderActor); actor != "" {
+		// Header values are only valid during the handler, so keep a copy
+		ctx.SetUserContext(utils.WithActor(ctx.UserContext(), strings.Clone(actor)))
+	}
+	return ctx.Next()
+}
+
+// RequestID stores the X-Request-ID of the request in the request context and echoes
+// it in the response. A missing or malformed ID is replaced with a new one, so a
+// caller can correlate its own logs with ours but cannot inject arbitrary text
+func RequestID(ctx *fiber.Ctx) error {
+	requestID := ctx.Get(fiber.HeaderXRequestID)
+	if validRequestID(requestID) {
+		requestID = strings.Clone(requestID)
+	} else {
+		requestID = uuid.NewString()
+	}
+
+	ctx.Set(fiber.HeaderXRequestID, requestID)
+	ctx.SetUserContext(utils.WithRequestID(ctx.UserContext(), requestID))
+	return ctx.Next()
+}
+
+// validRequestID accepts the characters of UUIDs and of the usual trace and span ID formats
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range requestID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// AccessLog writes one line per request. Server errors are logged at error level
+// together with the response, which carries the error message
+func AccessLog(logger *slog.Logger) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		startTime := time.Now()
+		self := ctx.Route()
+
+		// Run the error handler here so the logged status is the one sent
+		utils.HandleError(ctx, ctx.Next())
+
+		status := ctx.Response().StatusCode()
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Method()),
+			slog.String("route", utils.RouteTemplate(ctx, self)),
+			slog.String("path", ctx.Path()),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(startTime)),
+		}
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+			attrs = append(attrs, slog.String("response", string(ctx.Response().Body())))
+		}
+		logger.LogAttrs(ctx.UserContext(), level, "request", attrs...)
+		return nil
+	}
+}
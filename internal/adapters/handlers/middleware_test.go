@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/utils"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	recorder := &recordingProfilingService{}
+	app := fiber.New()
+	app.Use(RequestID)
+	app.Use(Profiling(recorder))
+	app.Get("/", func(ctx *fiber.Ctx) error {
+		return ctx.SendString(utils.RequestIDFromContext(ctx.UserContext()))
+	})
+
+	for _, tc := range []struct {
+		name   string
+		header string
+		kept   bool
+	}{
+		{"accepts a caller supplied ID", "4bf92f35-77b3:4da6.a3ce_929d", true},
+		{"generates a missing ID", "", false},
+		{"replaces an ID with unsafe characters", "evil\" injected=1", false},
+		{"replaces an overlong ID", strings.Repeat("a", maxRequestIDLength+1), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(fiber.HeaderXRequestID, tc.header)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+
+			var body bytes.Buffer
+			_, err = body.ReadFrom(resp.Body)
+			require.NoError(t, err)
+
+			requestID := resp.Header.Get(fiber.HeaderXRequestID)
+			assert.NotEmpty(t, requestID)
+			assert.Equal(t, requestID, body.String())
+			assert.Equal(t, tc.kept, requestID == tc.header)
+
+			// The profiling record carries the same ID
+			assert.Equal(t, requestID, recorder.records[len(recorder.records)-1].RequestID)
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var output bytes.Buffer
+	logger := slog.New(utils.ContextHandler(slog.NewJSONHandler(&output, nil)))
+
+	app := fiber.New()
+	app.Use(RequestID)
+	app.Use(AccessLog(logger))
+	app.Get("/products/:id", func(ctx *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusInternalServerError, "database on fire")
+	})
+
+	req := httptest.NewRequest("GET", "/products/42", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "req-1")
+	_, err := app.Test(req)
+	require.NoError(t, err)
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &line), output.String())
+	assert.Equal(t, "ERROR", line["level"])
+	assert.Equal(t, "request", line["msg"])
+	assert.Equal(t, "req-1", line["request_id"])
+	assert.Equal(t, "/products/:id", line["route"])
+	assert.Equal(t, "/products/42", line["path"])
+	assert.Equal(t, float64(500), line["status"])
+	assert.Equal(t, "database on fire", line["response"])
+}
@@ -3,86 +3,102 @@
 package handlers
 
 import (
-	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
 	"CRUD-Go-Hexa-MongoDB/internal/ports"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/google/uuid"
 )
 
 type ProdctHandler struct {
-	productService   ports.IProductService
-	profilingService ports.IProfilingService
+	productService ports.IProductService
 }
 
-func NewProductController(productService ports.IProductService, profilingService ports.IProfilingService) *ProdctHandler {
+func NewProductController(productService ports.IProductService) *ProdctHandler {
 	return &ProdctHandler{
-		productService:   productService,
-		profilingService: profilingService,
+		productService: productService,
 	}
 }
-func (c *ProdctHandler) logProfiling(apiCall string, startTime time.Time) error {
-	duration := time.Since(startTime).Milliseconds()
-
-	profiling := models.Profiling{
-		ID:        uuid.New(),
-		APICall:   apiCall,
-		Duration:  duration,
-		Timestamp: time.Now(),
-	}
-
-	c.profilingService.Log(profiling)
-
-	return nil
-}
 
 func (c *ProdctHandler) FindAll(ctx *fiber.Ctx) error {
-	startTime := time.Now()
-	response := c.productService.FindAll()
-	c.logProfiling("FindAll", startTime)
-	return ctx.Status(response.Code).JSON(response)
+	response := c.productService.FindAll(ctx.UserContext(), ctx.Queries())
+	return respond(ctx, response)
 }
 
 func (c *ProdctHandler) FindByID(ctx *fiber.Ctx) error {
-	startTime := time.Now()
 	idStr := ctx.Params("id")
-	response := c.productService.FindByID(idStr)
-	c.logProfiling("FindByID: "+idStr, startTime)
-	return ctx.Status(response.Code).JSON(response)
+	response := c.productService.FindByID(ctx.UserContext(), idStr)
+	return respond(ctx, response)
 }
 
 func (c *ProdctHandler) Create(ctx *fiber.Ctx) error {
-	startTime := time.Now()
-	productData := map[string]string{
-		"name":  ctx.FormValue("name"),
-		"stock": ctx.FormValue("stock"),
+	req, err := bindCreateProduct(ctx)
+	if err != nil {
+		return bindFailed(ctx, err)
 	}
 
-	response := c.productService.Create(productData)
-	c.logProfiling("Create", startTime)
-	return ctx.Status(response.Code).JSON(response)
+	response := c.productService.Create(ctx.UserContext(), req)
+	return respond(ctx, response)
 }
 
 func (c *ProdctHandler) Update(ctx *fiber.Ctx) error {
-	startTime := time.Now()
 	idStr := ctx.Params("id")
 
-	productData := map[string]string{
-		"name":  ctx.FormValue("name"),
-		"stock": ctx.FormValue("stock"),
+	expected, ok := parseIfMatch(ctx.Get(fiber.HeaderIfMatch))
+	if !ok {
+		return preconditionFailed(ctx)
 	}
 
-	response := c.productService.Update(idStr, productData)
-	c.logProfiling("Update :"+idStr, startTime)
-	return ctx.Status(response.Code).JSON(response)
+	req, err := bindUpdateProduct(ctx)
+	if err != nil {
+		return bindFailed(ctx, err)
+	}
+
+	response := c.productService.Update(ctx.UserContext(), idStr, req, expected)
+	return respond(ctx, response)
+}
+
+func (c *ProdctHandler) Patch(ctx *fiber.Ctx) error {
+	idStr := ctx.Params("id")
+
+	expected, ok := parseIfMatch(ctx.Get(fiber.HeaderIfMatch))
+	if !ok {
+		return preconditionFailed(ctx)
+	}
+
+	req, err := bindPatch(ctx)
+	if err != nil {
+		return bindFailed(ctx, err)
+	}
+
+	response := c.productService.Patch(ctx.UserContext(), idStr, req, expected)
+	return respond(ctx, response)
 }
 
 func (c *ProdctHandler) Delete(ctx *fiber.Ctx) error {
-	startTime := time.Now()
 	idStr := ctx.Params("id")
 
-	response := c.productService.Delete(idStr)
-	c.logProfiling("Delete: "+idStr, startTime)
-	return ctx.Status(response.Code).JSON(response)
+	expected, ok := parseIfMatch(ctx.Get(fiber.HeaderIfMatch))
+	if !ok {
+		return preconditionFailed(ctx)
+	}
+
+	response := c.productService.Delete(ctx.UserContext(), idStr, expected)
+	return respond(ctx, response)
+}
+
+func (c *ProdctHandler) AdjustStock(ctx *fiber.Ctx) error {
+	idStr := ctx.Params("id")
+
+	req, err := bindAdjustStock(ctx)
+	if err != nil {
+		return bindFailed(ctx, err)
+	}
+
+	response := c.productService.AdjustStock(ctx.UserContext(), idStr, req)
+	return respond(ctx, response)
+}
+
+func (c *ProdctHandler) ListMovements(ctx *fiber.Ctx) error {
+	idStr := ctx.Params("id")
+	response := c.productService.ListMovements(ctx.UserContext(), idStr, ctx.Queries())
+	return respond(ctx, response)
 }
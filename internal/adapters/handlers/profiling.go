@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"CRUD-Go-Hexa-MongoDB/internal/utils"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Profiling records one profiling entry per request, keyed by route template
+// rather than the raw path so calls to the same endpoint aggregate together
+func Profiling(profilingService ports.IProfilingService) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		startTime := time.Now()
+		self := ctx.Route()
+
+		// Run the error handler here so the recorded status is the one sent
+		utils.HandleError(ctx, ctx.Next())
+
+		method := strings.Clone(ctx.Method())
+		profiling := models.Profiling{
+			ID:           uuid.New(),
+			APICall:      method + " " + utils.UnmatchedRoute,
+			Method:       method,
+			Status:       ctx.Response().StatusCode(),
+			ResponseSize: int64(len(ctx.Response().Body())),
+			RequestID:    utils.RequestIDFromContext(ctx.UserContext()),
+			Duration:     time.Since(startTime).Microseconds(),
+		}
+
+		if route := utils.MatchedRoute(ctx, self); route != nil {
+			profiling.Route = route.Path
+			profiling.APICall = method + " " + route.Path
+			if len(route.Params) > 0 {
+				profiling.PathParams = make(map[string]string, len(route.Params))
+				for _, name := range route.Params {
+					// Params are only valid during the handler, so keep a copy
+					profiling.PathParams[name] = strings.Clone(ctx.Params(name))
+				}
+			}
+		}
+
+		// The record should still be written when the request itself was cancelled
+		profilingService.Log(context.WithoutCancel(ctx.UserContext()), profiling)
+		return nil
+	}
+}
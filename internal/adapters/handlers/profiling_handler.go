@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ProfilingHandler struct {
+	profilingService ports.IProfilingService
+}
+
+func NewProfilingController(profilingService ports.IProfilingService) *ProfilingHandler {
+	return &ProfilingHandler{
+		profilingService: profilingService,
+	}
+}
+
+func (c *ProfilingHandler) Summary(ctx *fiber.Ctx) error {
+	response := c.profilingService.Summarize(ctx.UserContext(), ctx.Queries())
+	return respond(ctx, response)
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/utils"
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingProfilingService struct {
+	mu      sync.Mutex
+	records []models.Profiling
+}
+
+func (s *recordingProfilingService) Log(ctx context.Context, profiling models.Profiling) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, profiling)
+	return nil
+}
+
+func (s *recordingProfilingService) Flush(ctx context.Context) error {
+	return nil
+}
+
+func (s *recordingProfilingService) Stats() models.ProfilingStats {
+	return models.ProfilingStats{}
+}
+
+func (s *recordingProfilingService) Summarize(ctx context.Context, queryParams map[string]string) utils.ServiceResponse {
+	return utils.ServiceResponse{}
+}
+
+func TestProfiling(t *testing.T) {
+	recorder := &recordingProfilingService{}
+	app := fiber.New()
+	app.Use(Profiling(recorder))
+	app.Get("/products/:id", func(ctx *fiber.Ctx) error {
+		return ctx.Status(fiber.StatusAccepted).SendString("hello")
+	})
+	app.Delete("/products/:id", func(ctx *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusConflict, "busy")
+	})
+
+	for _, req := range []struct{ method, path string }{
+		{"GET", "/products/42"},
+		{"DELETE", "/products/7"},
+		{"GET", "/nowhere/1"},
+	} {
+		_, err := app.Test(httptest.NewRequest(req.method, req.path, nil))
+		require.NoError(t, err)
+	}
+
+	require.Len(t, recorder.records, 3)
+
+	found := recorder.records[0]
+	assert.Equal(t, "GET /products/:id", found.APICall)
+	assert.Equal(t, "GET", found.Method)
+	assert.Equal(t, "/products/:id", found.Route)
+	assert.Equal(t, map[string]string{"id": "42"}, found.PathParams)
+	assert.Equal(t, fiber.StatusAccepted, found.Status)
+	assert.Equal(t, int64(len("hello")), found.ResponseSize)
+	assert.GreaterOrEqual(t, found.Duration, int64(0))
+
+	// Errors returned by handlers are recorded with the status the error handler sent
+	failed := recorder.records[1]
+	assert.Equal(t, "DELETE /products/:id", failed.APICall)
+	assert.Equal(t, fiber.StatusConflict, failed.Status)
+	assert.Equal(t, map[string]string{"id": "7"}, failed.PathParams)
+
+	unmatched := recorder.records[2]
+	assert.Equal(t, "GET "+utils.UnmatchedRoute, unmatched.APICall)
+	assert.Empty(t, unmatched.Route)
+	assert.Nil(t, unmatched.PathParams)
+	assert.Equal(t, fiber.StatusNotFound, unmatched.Status)
+}
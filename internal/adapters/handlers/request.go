@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/utils"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	MIMEMergePatch = "application/merge-patch+json"
+	MIMEJSONPatch  = "application/json-patch+json"
+)
+
+var errUnsupportedMediaType = errors.New("unsupported media type")
+
+// mediaType returns the request content type without parameters, e.g. "application/json"
+func mediaType(ctx *fiber.Ctx) string {
+	mediaType, _, err := mime.ParseMediaType(ctx.Get(fiber.HeaderContentType))
+	if err != nil {
+		return ""
+	}
+	return mediaType
+}
+
+// bindBody decodes a JSON, urlencoded or multipart body into dst. fields lists the form
+// fields to copy and how to parse them; absent form fields leave dst untouched
+func bindBody(ctx *fiber.Ctx, dst interface{}, fields map[string]func(value string) error) error {
+	switch mediaType(ctx) {
+	case fiber.MIMEApplicationJSON:
+		decoder := json.NewDecoder(bytes.NewReader(ctx.Body()))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(dst); err != nil {
+			return errors.New("Invalid JSON body: " + err.Error())
+		}
+		return nil
+	case fiber.MIMEApplicationForm, fiber.MIMEMultipartForm, "":
+		for key, parse := range fields {
+			if value, ok := formValue(ctx, key); ok {
+				if err := parse(value); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	default:
+		return errUnsupportedMediaType
+	}
+}
+
+// formValue returns a urlencoded or multipart field and whether the client sent it at all
+func formValue(ctx *fiber.Ctx, key string) (string, bool) {
+	if form, err := ctx.MultipartForm(); err == nil {
+		if values := form.Value[key]; len(values) > 0 {
+			return values[0], true
+		}
+		return "", false
+	}
+
+	args := ctx.Request().PostArgs()
+	if !args.Has(key) {
+		return "", false
+	}
+	return string(args.Peek(key)), true
+}
+
+func stringField(dst **string) func(string) error {
+	return func(value string) error {
+		*dst = &value
+		return nil
+	}
+}
+
+func intField(dst **int, message string) func(string) error {
+	return func(value string) error {
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.New(message)
+		}
+		*dst = &number
+		return nil
+	}
+}
+
+func bindCreateProduct(ctx *fiber.Ctx) (models.CreateProductRequest, error) {
+	var req models.CreateProductRequest
+	err := bindBody(ctx, &req, map[string]func(string) error{
+		"name":  stringField(&req.Name),
+		"stock": intField(&req.Stock, "Invalid Stock Value, must be a number and greater than 0"),
+	})
+	return req, err
+}
+
+func bindUpdateProduct(ctx *fiber.Ctx) (models.UpdateProductRequest, error) {
+	var req models.UpdateProductRequest
+	err := bindBody(ctx, &req, map[string]func(string) error{
+		"name":  stringField(&req.Name),
+		"stock": intField(&req.Stock, "Invalid Stock Value, must be a number and greater than 0"),
+	})
+	return req, err
+}
+
+func bindAdjustStock(ctx *fiber.Ctx) (models.AdjustStockRequest, error) {
+	var req models.AdjustStockRequest
+	err := bindBody(ctx, &req, map[string]func(string) error{
+		"delta": intField(&req.Delta, "Invalid Delta Value, must be a non-zero number"),
+		"reason": func(value string) error {
+			req.Reason = value
+			return nil
+		},
+	})
+	return req, err
+}
+
+// bindPatch selects the patch format from the content type; plain JSON is read as a merge patch
+func bindPatch(ctx *fiber.Ctx) (models.PatchProductRequest, error) {
+	req := models.PatchProductRequest{Document: append([]byte(nil), ctx.Body()...)}
+	switch mediaType(ctx) {
+	case MIMEMergePatch, fiber.MIMEApplicationJSON:
+		req.Format = models.MergePatch
+	case MIMEJSONPatch:
+		req.Format = models.JSONPatch
+	default:
+		return req, errUnsupportedMediaType
+	}
+	return req, nil
+}
+
+// bindFailed answers a request whose body could not be bound
+func bindFailed(ctx *fiber.Ctx, err error) error {
+	if errors.Is(err, errUnsupportedMediaType) {
+		return respond(ctx, utils.ServiceResponse{
+			Code:    fiber.StatusUnsupportedMediaType,
+			Message: "Unsupported Content-Type " + ctx.Get(fiber.HeaderContentType),
+			Data:    nil,
+		})
+	}
+	return respond(ctx, utils.ServiceResponse{
+		Code:    fiber.StatusBadRequest,
+		Message: "Validation error",
+		Data:    []string{err.Error()},
+	})
+}
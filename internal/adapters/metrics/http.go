@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Middleware observes the duration of every request, labelled by route template
+// so that paths with IDs do not each get their own series
+func (m *Metrics) Middleware(ctx *fiber.Ctx) error {
+	startTime := time.Now()
+	self := ctx.Route()
+
+	// Run the error handler here so the recorded status is the one sent
+	utils.HandleError(ctx, ctx.Next())
+
+	m.httpRequests.WithLabelValues(
+		strings.Clone(ctx.Method()),
+		utils.RouteTemplate(ctx, self),
+		strconv.Itoa(ctx.Response().StatusCode()),
+	).Observe(time.Since(startTime).Seconds())
+	return nil
+}
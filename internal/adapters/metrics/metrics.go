@@ -0,0 +1,97 @@
+// Prometheus instrumentation. Repositories are wrapped in decorators that time every
+// call, so the adapters themselves stay unaware of metrics
+package metrics
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"database/sql"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "hexa"
+
+// Metrics owns a registry, so several applications can live in one process (as in tests)
+type Metrics struct {
+	registry        *prometheus.Registry
+	httpRequests    *prometheus.HistogramVec
+	repositoryCalls *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests by method, route template and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		repositoryCalls: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_call_duration_seconds",
+			Help:      "Duration of repository calls by adapter, repository, operation and outcome.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"adapter", "repository", "operation", "outcome"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.repositoryCalls,
+	)
+	return m
+}
+
+// Handler serves the registry in the Prometheus text format
+func (m *Metrics) Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// RegisterDB exports the connection pool statistics of db under the given name
+func (m *Metrics) RegisterDB(name string, db *sql.DB) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterProfiling exports the state of the profiling write pipeline
+func (m *Metrics) RegisterProfiling(profilingService ports.IProfilingService) error {
+	gauges := []prometheus.Collector{
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "profiling_queue_depth",
+			Help:      "Profiling records waiting to be written.",
+		}, func() float64 { return float64(profilingService.Stats().Queued) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "profiling_records_written_total",
+			Help:      "Profiling records written to the store.",
+		}, func() float64 { return float64(profilingService.Stats().Written) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "profiling_records_dropped_total",
+			Help:      "Profiling records dropped because the queue was full or closed.",
+		}, func() float64 { return float64(profilingService.Stats().Dropped) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "profiling_records_failed_total",
+			Help:      "Profiling records in batches the store rejected.",
+		}, func() float64 { return float64(profilingService.Stats().Failed) }),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "profiling_records_skipped_total",
+			Help:      "Profiling records left out by the sampling rules.",
+		}, func() float64 { return float64(profilingService.Stats().Skipped) }),
+	}
+
+	for _, gauge := range gauges {
+		if err := m.registry.Register(gauge); err != nil {
+			return err
+		}
+	}
+	return nil
+}
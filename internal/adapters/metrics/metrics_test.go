@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	memoryRepo "CRUD-Go-Hexa-MongoDB/internal/adapters/repository/memory"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/utils"
+	"context"
+	"io"
+	"maps"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedProfilingService struct {
+	stats models.ProfilingStats
+}
+
+func (s fixedProfilingService) Log(ctx context.Context, profiling models.Profiling) error {
+	return nil
+}
+
+func (s fixedProfilingService) Flush(ctx context.Context) error {
+	return nil
+}
+
+func (s fixedProfilingService) Stats() models.ProfilingStats {
+	return s.stats
+}
+
+func (s fixedProfilingService) Summarize(ctx context.Context, queryParams map[string]string) utils.ServiceResponse {
+	return utils.ServiceResponse{}
+}
+
+func TestMiddleware(t *testing.T) {
+	m := New()
+	app := fiber.New()
+	app.Use(m.Middleware)
+	app.Get("/products/:id", func(ctx *fiber.Ctx) error {
+		return ctx.SendStatus(fiber.StatusOK)
+	})
+	app.Delete("/products/:id", func(ctx *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusConflict, "busy")
+	})
+
+	for _, req := range []struct{ method, path string }{
+		{"GET", "/products/1"},
+		{"GET", "/products/2"},
+		{"DELETE", "/products/3"},
+		{"GET", "/nowhere"},
+	} {
+		_, err := app.Test(httptest.NewRequest(req.method, req.path, nil))
+		require.NoError(t, err)
+	}
+
+	// Requests are grouped by route template, not by path
+	assert.Equal(t, 3, testutil.CollectAndCount(m.httpRequests))
+	assert.Equal(t, uint64(2), sampleCount(t, m, "hexa_http_request_duration_seconds",
+		map[string]string{"method": "GET", "route": "/products/:id", "status": "200"}))
+	assert.Equal(t, uint64(1), sampleCount(t, m, "hexa_http_request_duration_seconds",
+		map[string]string{"method": "DELETE", "route": "/products/:id", "status": "409"}))
+	assert.Equal(t, uint64(1), sampleCount(t, m, "hexa_http_request_duration_seconds",
+		map[string]string{"method": "GET", "route": utils.UnmatchedRoute, "status": "404"}))
+}
+
+func TestRepositoryDecorators(t *testing.T) {
+	m := New()
+	store := memoryRepo.NewStore()
+	products := m.ProductRepository(memoryRepo.NewProductRepository(store), "memory")
+	ctx := context.Background()
+
+	product := models.Product{ID: uuid.New(), Name: "Widget", Stock: 1, Version: 1}
+	require.NoError(t, products.Create(ctx, product))
+	_, err := products.FindByID(ctx, product.ID)
+	require.NoError(t, err)
+	_, err = products.FindByID(ctx, uuid.New())
+	require.Error(t, err)
+
+	// Errors are labelled with their kind
+	for _, outcome := range []struct{ operation, outcome string }{
+		{"create", "ok"},
+		{"find_by_id", "ok"},
+		{"find_by_id", "not_found"},
+	} {
+		assert.Equal(t, uint64(1), sampleCount(t, m, "hexa_repository_call_duration_seconds", map[string]string{
+			"adapter": "memory", "repository": "products", "operation": outcome.operation, "outcome": outcome.outcome,
+		}), outcome)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	m := New()
+	require.NoError(t, m.RegisterProfiling(fixedProfilingService{stats: models.ProfilingStats{Queued: 7, Dropped: 2}}))
+
+	app := fiber.New()
+	app.Get("/metrics", m.Handler())
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "hexa_profiling_queue_depth 7")
+	assert.Contains(t, string(body), "hexa_profiling_records_dropped_total 2")
+	assert.Contains(t, string(body), "go_goroutines")
+}
+
+// sampleCount returns how many observations the histogram series with exactly labels holds
+func sampleCount(t *testing.T, m *Metrics, name string, labels map[string]string) uint64 {
+	t.Helper()
+
+	families, err := m.registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			got := map[string]string{}
+			for _, pair := range metric.GetLabel() {
+				got[pair.GetName()] = pair.GetValue()
+			}
+			if maps.Equal(got, labels) {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
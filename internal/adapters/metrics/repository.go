@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// call times one repository operation. The outcome is "ok" or the error kind, so
+// expected conflicts and not-founds can be told apart from failures
+type call struct {
+	metrics    *Metrics
+	adapter    string
+	repository string
+}
+
+func (c call) observe(operation string, startTime time.Time, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = strings.ReplaceAll(errs.KindOf(err).String(), " ", "_")
+	}
+	c.metrics.repositoryCalls.WithLabelValues(c.adapter, c.repository, operation, outcome).
+		Observe(time.Since(startTime).Seconds())
+}
+
+type productRepository struct {
+	next ports.IProductRepository
+	call call
+}
+
+// ProductRepository times the calls made to next, labelled with the adapter name
+func (m *Metrics) ProductRepository(next ports.IProductRepository, adapter string) ports.IProductRepository {
+	return &productRepository{next: next, call: call{metrics: m, adapter: adapter, repository: "products"}}
+}
+
+func (r *productRepository) FindAll(ctx context.Context, query models.ProductQuery) (models.ProductPage, error) {
+	startTime := time.Now()
+	page, err := r.next.FindAll(ctx, query)
+	r.call.observe("find_all", startTime, err)
+	return page, err
+}
+
+func (r *productRepository) FindByID(ctx context.Context, id uuid.UUID) (models.Product, error) {
+	startTime := time.Now()
+	product, err := r.next.FindByID(ctx, id)
+	r.call.observe("find_by_id", startTime, err)
+	return product, err
+}
+
+func (r *productRepository) Create(ctx context.Context, product models.Product) error {
+	startTime := time.Now()
+	err := r.next.Create(ctx, product)
+	r.call.observe("create", startTime, err)
+	return err
+}
+
+func (r *productRepository) Update(ctx context.Context, product models.Product) (models.Product, error) {
+	startTime := time.Now()
+	updated, err := r.next.Update(ctx, product)
+	r.call.observe("update", startTime, err)
+	return updated, err
+}
+
+func (r *productRepository) AdjustStock(ctx context.Context, id uuid.UUID, delta int, updatedAt time.Time) (models.Product, error) {
+	startTime := time.Now()
+	product, err := r.next.AdjustStock(ctx, id, delta, updatedAt)
+	r.call.observe("adjust_stock", startTime, err)
+	return product, err
+}
+
+func (r *productRepository) Delete(ctx context.Context, id uuid.UUID, expectedVersion int64) error {
+	startTime := time.Now()
+	err := r.next.Delete(ctx, id, expectedVersion)
+	r.call.observe("delete", startTime, err)
+	return err
+}
+
+type stockMovementRepository struct {
+	next ports.IStockMovementRepository
+	call call
+}
+
+// StockMovementRepository times the calls made to next, labelled with the adapter name
+func (m *Metrics) StockMovementRepository(next ports.IStockMovementRepository, adapter string) ports.IStockMovementRepository {
+	return &stockMovementRepository{next: next, call: call{metrics: m, adapter: adapter, repository: "stock_movements"}}
+}
+
+func (r *stockMovementRepository) Append(ctx context.Context, movement models.StockMovement) error {
+	startTime := time.Now()
+	err := r.next.Append(ctx, movement)
+	r.call.observe("append", startTime, err)
+	return err
+}
+
+func (r *stockMovementRepository) List(ctx context.Context, query models.MovementQuery) (models.MovementPage, error) {
+	startTime := time.Now()
+	page, err := r.next.List(ctx, query)
+	r.call.observe("list", startTime, err)
+	return page, err
+}
+
+func (r *stockMovementRepository) BalanceAt(ctx context.Context, productID uuid.UUID, at time.Time) (int, error) {
+	startTime := time.Now()
+	balance, err := r.next.BalanceAt(ctx, productID, at)
+	r.call.observe("balance_at", startTime, err)
+	return balance, err
+}
+
+type transactor struct {
+	next ports.ITransactor
+	call call
+}
+
+// Transactor times whole transactions, including the work fn does inside them
+func (m *Metrics) Transactor(next ports.ITransactor, adapter string) ports.ITransactor {
+	return &transactor{next: next, call: call{metrics: m, adapter: adapter, repository: "transactor"}}
+}
+
+func (t *transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	startTime := time.Now()
+	err := t.next.WithinTransaction(ctx, fn)
+	t.call.observe("within_transaction", startTime, err)
+	return err
+}
+
+type profilingRepository struct {
+	next ports.IProfilingRepository
+	call call
+}
+
+// ProfilingRepository times the calls made to next, labelled with the adapter name
+func (m *Metrics) ProfilingRepository(next ports.IProfilingRepository, adapter string) ports.IProfilingRepository {
+	return &profilingRepository{next: next, call: call{metrics: m, adapter: adapter, repository: "profiling"}}
+}
+
+func (r *profilingRepository) CreateMany(ctx context.Context, records []models.Profiling) error {
+	startTime := time.Now()
+	err := r.next.CreateMany(ctx, records)
+	r.call.observe("create_many", startTime, err)
+	return err
+}
+
+func (r *profilingRepository) Summarize(ctx context.Context, query models.ProfilingQuery) ([]models.RouteStats, error) {
+	startTime := time.Now()
+	stats, err := r.next.Summarize(ctx, query)
+	r.call.observe("summarize", startTime, err)
+	return stats, err
+}
@@ -0,0 +1,164 @@
+package memory
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ProductRepository struct {
+	store *Store
+}
+
+func NewProductRepository(store *Store) ports.IProductRepository {
+	return &ProductRepository{store: store}
+}
+
+func (r *ProductRepository) FindAll(ctx context.Context, query models.ProductQuery) (models.ProductPage, error) {
+	var page models.ProductPage
+	if query.SortBy != models.SortByName && query.SortBy != models.SortByStock {
+		return page, errs.Validation("unsupported sort field %q", query.SortBy)
+	}
+
+	unlock := r.store.lock(ctx)
+	var matches []models.Product
+	for _, p := range r.store.products {
+		if matchesFilters(p, query) {
+			matches = append(matches, p)
+		}
+	}
+	unlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		return compareProducts(matches[i], matches[j], query.SortBy, query.Descending) < 0
+	})
+	page.Total = int64(len(matches))
+
+	if query.After != nil {
+		after := models.Product{ID: query.After.ID, Name: query.After.Name, Stock: query.After.Stock}
+		start := sort.Search(len(matches), func(i int) bool {
+			return compareProducts(matches[i], after, query.SortBy, query.Descending) > 0
+		})
+		matches = matches[start:]
+	}
+
+	if query.Offset >= len(matches) {
+		matches = nil
+	} else {
+		matches = matches[query.Offset:]
+	}
+	if len(matches) > query.Limit {
+		matches = matches[:query.Limit]
+		page.HasMore = true
+	}
+
+	page.Items = matches
+	return page, nil
+}
+
+func matchesFilters(p models.Product, query models.ProductQuery) bool {
+	if query.NameContains != "" && !strings.Contains(strings.ToLower(p.Name), strings.ToLower(query.NameContains)) {
+		return false
+	}
+	if query.MinStock != nil && p.Stock < *query.MinStock {
+		return false
+	}
+	if query.MaxStock != nil && p.Stock > *query.MaxStock {
+		return false
+	}
+	return true
+}
+
+// compareProducts orders by the sort field, then by ID, in the requested direction.
+// Names compare byte-wise, like in the SQL and MongoDB adapters
+func compareProducts(a, b models.Product, sortBy models.ProductSortField, descending bool) int {
+	var result int
+	switch {
+	case sortBy == models.SortByStock && a.Stock != b.Stock:
+		result = a.Stock - b.Stock
+	case sortBy == models.SortByName && a.Name != b.Name:
+		result = strings.Compare(a.Name, b.Name)
+	default:
+		result = bytes.Compare(a.ID[:], b.ID[:])
+	}
+	if descending {
+		return -result
+	}
+	return result
+}
+
+func (r *ProductRepository) FindByID(ctx context.Context, id uuid.UUID) (models.Product, error) {
+	defer r.store.lock(ctx)()
+
+	p, ok := r.store.products[id]
+	if !ok {
+		return models.Product{}, errs.NotFound("product %s not found", id)
+	}
+	return p, nil
+}
+
+func (r *ProductRepository) Create(ctx context.Context, p models.Product) error {
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.products[p.ID]; ok {
+		return errs.Conflict("product %s already exists", p.ID)
+	}
+	r.store.putProduct(ctx, p)
+	return nil
+}
+
+func (r *ProductRepository) Update(ctx context.Context, p models.Product) (models.Product, error) {
+	defer r.store.lock(ctx)()
+
+	stored, ok := r.store.products[p.ID]
+	if !ok {
+		return models.Product{}, errs.NotFound("product %s not found", p.ID)
+	}
+	if stored.Version != p.Version {
+		return models.Product{}, errs.Conflict("product %s was modified concurrently", p.ID)
+	}
+
+	p.Version++
+	r.store.putProduct(ctx, p)
+	return p, nil
+}
+
+func (r *ProductRepository) AdjustStock(ctx context.Context, id uuid.UUID, delta int, updatedAt time.Time) (models.Product, error) {
+	defer r.store.lock(ctx)()
+
+	p, ok := r.store.products[id]
+	if !ok {
+		return models.Product{}, errs.NotFound("product %s not found", id)
+	}
+	if p.Stock+delta < 0 {
+		return models.Product{}, errs.Conflict("adjusting product %s by %d would make stock negative", id, delta)
+	}
+
+	p.Stock += delta
+	p.Version++
+	p.UpdatedAt = updatedAt
+	r.store.putProduct(ctx, p)
+	return p, nil
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, id uuid.UUID, expectedVersion int64) error {
+	defer r.store.lock(ctx)()
+
+	p, ok := r.store.products[id]
+	if !ok {
+		return errs.NotFound("product %s not found", id)
+	}
+	if expectedVersion != 0 && p.Version != expectedVersion {
+		return errs.PreconditionFailed("product %s does not have version %d", id, expectedVersion)
+	}
+
+	r.store.deleteProduct(ctx, id)
+	return nil
+}
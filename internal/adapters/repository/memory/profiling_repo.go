@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"sync"
+	"time"
+)
+
+// maxProfilingRecords bounds the records kept, the oldest go first
+const maxProfilingRecords = 100_000
+
+type ProfilingRepository struct {
+	mu  sync.Mutex
+	ttl time.Duration
+	// records are in the order they were written
+	records []models.Profiling
+}
+
+// NewProfilingRepository keeps records for ttl, or until there are too many when ttl is 0
+func NewProfilingRepository(ttl time.Duration) ports.IProfilingRepository {
+	return &ProfilingRepository{ttl: ttl}
+}
+
+func (r *ProfilingRepository) CreateMany(ctx context.Context, records []models.Profiling) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records = append(r.records, records...)
+	r.prune(time.Now())
+	return nil
+}
+
+// prune drops the records older than the TTL and the oldest beyond maxProfilingRecords.
+// Records arrive roughly in timestamp order, so expired ones are at the front
+func (r *ProfilingRepository) prune(now time.Time) {
+	drop := max(len(r.records)-maxProfilingRecords, 0)
+	if r.ttl > 0 {
+		cutoff := now.Add(-r.ttl)
+		for drop < len(r.records) && r.records[drop].Timestamp.Before(cutoff) {
+			drop++
+		}
+	}
+	if drop > 0 {
+		// Copy rather than reslice so the dropped records can be collected
+		r.records = append([]models.Profiling(nil), r.records[drop:]...)
+	}
+}
+
+func (r *ProfilingRepository) Summarize(ctx context.Context, query models.ProfilingQuery) ([]models.RouteStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return models.SummarizeProfiling(r.records, query), nil
+}
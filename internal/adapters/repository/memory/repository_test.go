@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/adapters/repository/repotest"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		store := NewStore()
+		return repotest.Repositories{
+			Products:   NewProductRepository(store),
+			Movements:  NewStockMovementRepository(store),
+			Transactor: NewTransactor(store),
+		}
+	})
+}
+
+func TestProfilingConformance(t *testing.T) {
+	repotest.RunProfiling(t, func(t *testing.T) ports.IProfilingRepository {
+		return NewProfilingRepository(0)
+	})
+}
+
+func TestTransactionUndoesOnlyTouchedProducts(t *testing.T) {
+	ctx := context.Background()
+	store := NewStore()
+	products, transactor := NewProductRepository(store), NewTransactor(store)
+
+	kept := repotest.NewProduct("Kept", 1)
+	deleted := repotest.NewProduct("Deleted", 2)
+	require.NoError(t, products.Create(ctx, kept))
+	require.NoError(t, products.Create(ctx, deleted))
+	created := repotest.NewProduct("Created", 3)
+
+	err := transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Two changes to one product undo to the value before the first
+		if _, err := products.AdjustStock(ctx, kept.ID, 5, time.Now()); err != nil {
+			return err
+		}
+		if _, err := products.AdjustStock(ctx, kept.ID, 5, time.Now()); err != nil {
+			return err
+		}
+		if err := products.Delete(ctx, deleted.ID, 0); err != nil {
+			return err
+		}
+		if err := products.Create(ctx, created); err != nil {
+			return err
+		}
+		return errs.Conflict("abort")
+	})
+	assert.ErrorIs(t, err, errs.ErrConflict)
+
+	found, err := products.FindByID(ctx, kept.ID)
+	require.NoError(t, err)
+	assert.Equal(t, kept, found)
+	found, err = products.FindByID(ctx, deleted.ID)
+	require.NoError(t, err)
+	assert.Equal(t, deleted, found)
+	_, err = products.FindByID(ctx, created.ID)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+}
+
+func TestProfilingRetention(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("drops expired records", func(t *testing.T) {
+		repo := NewProfilingRepository(time.Hour).(*ProfilingRepository)
+		require.NoError(t, repo.CreateMany(ctx, []models.Profiling{
+			{ID: uuid.New(), Timestamp: now.Add(-2 * time.Hour)},
+			{ID: uuid.New(), Timestamp: now.Add(-time.Minute)},
+		}))
+		require.Len(t, repo.records, 1)
+		assert.Equal(t, now.Add(-time.Minute), repo.records[0].Timestamp)
+	})
+
+	t.Run("keeps the newest records", func(t *testing.T) {
+		repo := NewProfilingRepository(0).(*ProfilingRepository)
+		records := make([]models.Profiling, maxProfilingRecords+10)
+		for i := range records {
+			records[i] = models.Profiling{Duration: int64(i), Timestamp: now}
+		}
+		require.NoError(t, repo.CreateMany(ctx, records))
+		require.Len(t, repo.records, maxProfilingRecords)
+		assert.Equal(t, int64(10), repo.records[0].Duration)
+	})
+}
@@ -0,0 +1,73 @@
+package memory
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StockMovementRepository struct {
+	store *Store
+}
+
+func NewStockMovementRepository(store *Store) ports.IStockMovementRepository {
+	return &StockMovementRepository{store: store}
+}
+
+func (r *StockMovementRepository) Append(ctx context.Context, movement models.StockMovement) error {
+	defer r.store.lock(ctx)()
+
+	for i := len(r.store.movements) - 1; i >= 0; i-- {
+		if previous := r.store.movements[i]; previous.ProductID == movement.ProductID {
+			if previous.CreatedAt.After(movement.CreatedAt) {
+				movement.CreatedAt = previous.CreatedAt
+			}
+			break
+		}
+	}
+	r.store.movements = append(r.store.movements, movement)
+	return nil
+}
+
+func (r *StockMovementRepository) List(ctx context.Context, query models.MovementQuery) (models.MovementPage, error) {
+	defer r.store.lock(ctx)()
+
+	var page models.MovementPage
+	var skipped int
+
+	// The ledger is kept in insertion order, so walk it backwards for newest first
+	for i := len(r.store.movements) - 1; i >= 0; i-- {
+		m := r.store.movements[i]
+		if m.ProductID != query.ProductID ||
+			(query.From != nil && m.CreatedAt.Before(*query.From)) ||
+			(query.To != nil && m.CreatedAt.After(*query.To)) {
+			continue
+		}
+
+		page.Total++
+		switch {
+		case skipped < query.Offset:
+			skipped++
+		case len(page.Items) < query.Limit:
+			page.Items = append(page.Items, m)
+		default:
+			page.HasMore = true
+		}
+	}
+	return page, nil
+}
+
+func (r *StockMovementRepository) BalanceAt(ctx context.Context, productID uuid.UUID, at time.Time) (int, error) {
+	defer r.store.lock(ctx)()
+
+	for i := len(r.store.movements) - 1; i >= 0; i-- {
+		m := r.store.movements[i]
+		if m.ProductID == productID && !m.CreatedAt.After(at) {
+			return m.Balance, nil
+		}
+	}
+	return 0, nil
+}
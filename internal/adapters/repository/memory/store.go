@@ -0,0 +1,115 @@
+// In-memory adapters for local development and tests. State lives for the life of the process
+package memory
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Store holds products and their stock ledger. Repositories built on the same Store
+// share its lock, which lets the Transactor make their changes atomic
+type Store struct {
+	mu        sync.Mutex
+	products  map[uuid.UUID]models.Product
+	movements []models.StockMovement
+}
+
+func NewStore() *Store {
+	return &Store{
+		products: make(map[uuid.UUID]models.Product),
+	}
+}
+
+type txKey struct{}
+
+// transaction holds the store lock and remembers how to undo its changes: the value
+// each product it changed had before, nil for products it created
+type transaction struct {
+	store    *Store
+	original map[uuid.UUID]*models.Product
+}
+
+// transaction returns the transaction of s that ctx belongs to, if any
+func (s *Store) transaction(ctx context.Context) *transaction {
+	if tx, ok := ctx.Value(txKey{}).(*transaction); ok && tx.store == s {
+		return tx
+	}
+	return nil
+}
+
+// lock acquires the store unless ctx belongs to a transaction that already holds it
+func (s *Store) lock(ctx context.Context) func() {
+	if s.transaction(ctx) != nil {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+// remember records the product's value before the transaction of ctx first changes it
+func (s *Store) remember(ctx context.Context, id uuid.UUID) {
+	tx := s.transaction(ctx)
+	if tx == nil {
+		return
+	}
+	if _, ok := tx.original[id]; ok {
+		return
+	}
+	if p, ok := s.products[id]; ok {
+		tx.original[id] = &p
+	} else {
+		tx.original[id] = nil
+	}
+}
+
+// putProduct stores p, with the lock held
+func (s *Store) putProduct(ctx context.Context, p models.Product) {
+	s.remember(ctx, p.ID)
+	s.products[p.ID] = p
+}
+
+// deleteProduct removes a product, with the lock held
+func (s *Store) deleteProduct(ctx context.Context, id uuid.UUID) {
+	s.remember(ctx, id)
+	delete(s.products, id)
+}
+
+type Transactor struct {
+	store *Store
+}
+
+func NewTransactor(store *Store) ports.ITransactor {
+	return &Transactor{store: store}
+}
+
+// WithinTransaction holds the store lock for the whole of fn, undoing its changes if
+// fn fails. Only the products fn changes are copied. Nested calls join the outer transaction
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if t.store.transaction(ctx) != nil {
+		return fn(ctx)
+	}
+
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+
+	tx := &transaction{store: t.store, original: make(map[uuid.UUID]*models.Product)}
+	// The ledger is append-only, so undoing it is truncating it
+	movementCount := len(t.store.movements)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		for id, p := range tx.original {
+			if p == nil {
+				delete(t.store.products, id)
+			} else {
+				t.store.products[id] = *p
+			}
+		}
+		t.store.movements = t.store.movements[:movementCount]
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package mongo
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Server error codes the adapters react to
+const (
+	// commandNotFound is returned for commands the server does not know
+	commandNotFound = 59
+	// namespaceExists is returned when creating a collection that already exists
+	namespaceExists = 48
+	// documentValidationFailure is returned for writes rejected by $jsonSchema
+	documentValidationFailure = 121
+	// unknownPipelineStage is returned for aggregation stages the server does not support
+	unknownPipelineStage = 40324
+)
+
+// mapError translates mongo driver errors into domain errors
+func mapError(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+
+	var serverErr mongo.ServerError
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return errs.Wrap(errs.KindNotFound, err, message)
+	case mongo.IsDuplicateKeyError(err):
+		return errs.Wrap(errs.KindConflict, err, message)
+	case errors.As(err, &serverErr) && serverErr.HasErrorCode(documentValidationFailure):
+		return errs.Wrap(errs.KindValidation, err, message)
+	case errors.Is(err, context.Canceled):
+		return errs.Wrap(errs.KindCanceled, err, message)
+	case errors.Is(err, context.DeadlineExceeded):
+		return errs.Wrap(errs.KindTimeout, err, message)
+	case mongo.IsNetworkError(err), mongo.IsTimeout(err), errors.Is(err, mongo.ErrClientDisconnected):
+		return errs.Wrap(errs.KindUnavailable, err, message)
+	}
+
+	return errs.Wrap(errs.KindInternal, err, message)
+}
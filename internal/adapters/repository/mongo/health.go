@@ -0,0 +1,22 @@
+package mongo
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+type HealthChecker struct {
+	client *mongo.Client
+}
+
+// NewHealthChecker checks that the primary answers, since every write goes there
+func NewHealthChecker(client *mongo.Client) ports.IHealthChecker {
+	return &HealthChecker{client: client}
+}
+
+func (c *HealthChecker) Check(ctx context.Context) error {
+	return mapError(c.client.Ping(ctx, readpref.Primary()), "ping MongoDB")
+}
@@ -0,0 +1,27 @@
+package mongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureIndexes creates the indexes the product adapters query by. Creating an
+// index that already exists with the same options is a no-op, so it is safe on every startup
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("products").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}, Options: options.Index().SetName("name_id")},
+		{Keys: bson.D{{Key: "stock", Value: 1}, {Key: "_id", Value: 1}}, Options: options.Index().SetName("stock_id")},
+	})
+	if err != nil {
+		return mapError(err, "create product indexes")
+	}
+
+	_, err = db.Collection("stock_movements").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "seq", Value: -1}}, Options: options.Index().SetName("product_seq")},
+		{Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "created_at", Value: 1}}, Options: options.Index().SetName("product_created_at")},
+	})
+	return mapError(err, "create stock movement indexes")
+}
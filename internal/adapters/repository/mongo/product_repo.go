@@ -0,0 +1,199 @@
+package mongo
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// productDocument is the stored shape of a product. IDs are kept as generic binary
+// so _id ordering matches the byte order used by the other adapters
+type productDocument struct {
+	ID        uuid.UUID `bson:"_id"`
+	Name      string    `bson:"name"`
+	Stock     int       `bson:"stock"`
+	Version   int64     `bson:"version"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+func (d productDocument) toModel() models.Product {
+	return models.Product{ID: d.ID, Name: d.Name, Stock: d.Stock, Version: d.Version, UpdatedAt: d.UpdatedAt}
+}
+
+type ProductRepository struct {
+	collection *mongo.Collection
+}
+
+func NewProductRepository(db *mongo.Database) ports.IProductRepository {
+	return &ProductRepository{
+		collection: db.Collection("products"),
+	}
+}
+
+// sortFields whitelists the fields a query may order by. Without a collation
+// MongoDB sorts names byte-wise, like the other adapters
+var sortFields = map[models.ProductSortField]string{
+	models.SortByName:  "name",
+	models.SortByStock: "stock",
+}
+
+func (r *ProductRepository) FindAll(ctx context.Context, query models.ProductQuery) (models.ProductPage, error) {
+	var page models.ProductPage
+
+	field, ok := sortFields[query.SortBy]
+	if !ok {
+		return page, errs.Validation("unsupported sort field %q", query.SortBy)
+	}
+
+	filter := bson.D{}
+	if query.NameContains != "" {
+		filter = append(filter, bson.E{Key: "name", Value: bson.M{"$regex": regexp.QuoteMeta(query.NameContains), "$options": "i"}})
+	}
+	stock := bson.M{}
+	if query.MinStock != nil {
+		stock["$gte"] = *query.MinStock
+	}
+	if query.MaxStock != nil {
+		stock["$lte"] = *query.MaxStock
+	}
+	if len(stock) > 0 {
+		filter = append(filter, bson.E{Key: "stock", Value: stock})
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return page, mapError(err, "count products")
+	}
+	page.Total = total
+
+	direction, comparison := 1, "$gt"
+	if query.Descending {
+		direction, comparison = -1, "$lt"
+	}
+
+	if query.After != nil {
+		var after interface{} = query.After.Name
+		if query.SortBy == models.SortByStock {
+			after = query.After.Stock
+		}
+		filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: field, Value: bson.M{comparison: after}}},
+			bson.D{{Key: field, Value: after}, {Key: "_id", Value: bson.M{comparison: query.After.ID}}},
+		}})
+	}
+
+	// Fetch one extra document to learn whether another page follows
+	opts := options.Find().
+		SetSort(bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}).
+		SetSkip(int64(query.Offset)).
+		SetLimit(int64(query.Limit + 1))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return page, mapError(err, "query products")
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc productDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return page, mapError(err, "decode product")
+		}
+		page.Items = append(page.Items, doc.toModel())
+	}
+	if err := cursor.Err(); err != nil {
+		return page, mapError(err, "iterate products")
+	}
+
+	if len(page.Items) > query.Limit {
+		page.Items = page.Items[:query.Limit]
+		page.HasMore = true
+	}
+	return page, nil
+}
+
+func (r *ProductRepository) FindByID(ctx context.Context, id uuid.UUID) (models.Product, error) {
+	var doc productDocument
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&doc)
+	return doc.toModel(), mapError(err, "find product "+id.String())
+}
+
+func (r *ProductRepository) Create(ctx context.Context, p models.Product) error {
+	_, err := r.collection.InsertOne(ctx, productDocument{
+		ID:        p.ID,
+		Name:      p.Name,
+		Stock:     p.Stock,
+		Version:   p.Version,
+		UpdatedAt: p.UpdatedAt,
+	})
+	return mapError(err, "insert product")
+}
+
+func (r *ProductRepository) Update(ctx context.Context, p models.Product) (models.Product, error) {
+	var doc productDocument
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": p.ID, "version": p.Version},
+		bson.M{
+			"$set": bson.M{"name": p.Name, "stock": p.Stock, "updated_at": p.UpdatedAt},
+			"$inc": bson.M{"version": 1},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Product{}, r.notFoundOr(ctx, p.ID, errs.Conflict("product %s was modified concurrently", p.ID))
+	}
+	return doc.toModel(), mapError(err, "update product "+p.ID.String())
+}
+
+func (r *ProductRepository) AdjustStock(ctx context.Context, id uuid.UUID, delta int, updatedAt time.Time) (models.Product, error) {
+	var doc productDocument
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "stock": bson.M{"$gte": -delta}},
+		bson.M{
+			"$set": bson.M{"updated_at": updatedAt},
+			"$inc": bson.M{"stock": delta, "version": 1},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return models.Product{}, r.notFoundOr(ctx, id, errs.Conflict("adjusting product %s by %d would make stock negative", id, delta))
+	}
+	return doc.toModel(), mapError(err, "adjust stock of product "+id.String())
+}
+
+func (r *ProductRepository) Delete(ctx context.Context, id uuid.UUID, expectedVersion int64) error {
+	filter := bson.M{"_id": id}
+	if expectedVersion != 0 {
+		filter["version"] = expectedVersion
+	}
+
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return mapError(err, "delete product "+id.String())
+	}
+	if result.DeletedCount == 0 {
+		return r.notFoundOr(ctx, id, errs.PreconditionFailed("product %s does not have version %d", id, expectedVersion))
+	}
+	return nil
+}
+
+// notFoundOr tells a missing product apart from a conditional write that failed on an existing one
+func (r *ProductRepository) notFoundOr(ctx context.Context, id uuid.UUID, conditionErr error) error {
+	count, err := r.collection.CountDocuments(ctx, bson.M{"_id": id}, options.Count().SetLimit(1))
+	if err != nil {
+		return mapError(err, "find product "+id.String())
+	}
+	if count == 0 {
+		return errs.NotFound("product %s not found", id)
+	}
+	return conditionErr
+}
@@ -1,12 +1,17 @@
 package mongo
 
 import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
 	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
 	"CRUD-Go-Hexa-MongoDB/internal/ports"
 	"context"
+	"errors"
+	"math"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ProfilingRepository struct {
@@ -15,14 +20,176 @@ type ProfilingRepository struct {
 
 func NewProfilingRepository(db *mongo.Database) ports.IProfilingRepository {
 	return &ProfilingRepository{
-		collection: db.Collection("profiling"),
+		collection: db.Collection(profilingCollection),
 	}
 }
 
-func (r *ProfilingRepository) Create(profiling models.Profiling) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+func (r *ProfilingRepository) CreateMany(ctx context.Context, records []models.Profiling) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, len(records))
+	for i, record := range records {
+		record.Weight = record.SampleWeight()
+		documents[i] = record
+	}
+
+	// Unordered, so one rejected record does not stop the rest of the batch
+	_, err := r.collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	// Each rejected record has its own write error, the others were inserted
+	var bulk mongo.BulkWriteException
+	if errors.As(err, &bulk) && bulk.WriteConcernError == nil && len(bulk.WriteErrors) < len(records) {
+		return errs.Partial(len(bulk.WriteErrors), mapError(err, "insert profiling records"))
+	}
+	return mapError(err, "insert profiling records")
+}
+
+// maxSummarizedInGo bounds the records one summary reads when the server cannot
+// compute percentiles itself
+const maxSummarizedInGo = 1_000_000
+
+// summaryRow is one group of the summary pipeline, its counts weighted
+type summaryRow struct {
+	ID struct {
+		Window float64 `bson:"window"`
+		Method string  `bson:"method"`
+		Route  string  `bson:"route"`
+	} `bson:"_id"`
+	Count  float64 `bson:"count"`
+	Errors float64 `bson:"errors"`
+	P50    int64   `bson:"p50"`
+	P95    int64   `bson:"p95"`
+	P99    int64   `bson:"p99"`
+}
+
+// Summarize aggregates on the server. $setWindowFields needs MongoDB 5.0, older
+// servers fall back to aggregating the matching records in Go
+func (r *ProfilingRepository) Summarize(ctx context.Context, query models.ProfilingQuery) ([]models.RouteStats, error) {
+	// A window longer than the range puts everything in window 0
+	window := query.Window
+	if window <= 0 {
+		window = query.To.Sub(query.From) + time.Second
+	}
+
+	match := bson.M{"timestamp": bson.M{"$gte": query.From, "$lt": query.To}}
+	if query.Method != "" {
+		match["method"] = query.Method
+	}
+	if query.Route != "" {
+		match["route"] = query.Route
+	}
+
+	// Weighted nearest-rank percentiles, like models.ProfilingSummarizer: the first
+	// duration whose running weight reaches the share of the group's total weight.
+	// The slack absorbs the rounding of summed fractional weights
+	percentile := func(p float64) bson.M {
+		return bson.M{"$min": bson.M{"$cond": bson.A{
+			bson.M{"$gte": bson.A{"$running", bson.M{"$subtract": bson.A{bson.M{"$multiply": bson.A{p, "$total"}}, 1e-9}}}},
+			"$duration",
+			// $min skips nulls
+			nil,
+		}}}
+	}
+	group := bson.M{"window": "$window", "method": "$method", "route": "$route"}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$set", Value: bson.M{
+			// Subtracting dates yields milliseconds
+			"window": bson.M{"$floor": bson.M{"$divide": bson.A{
+				bson.M{"$subtract": bson.A{"$timestamp", query.From}}, window.Milliseconds(),
+			}}},
+			// Records from before sampling have no weight
+			"weight": bson.M{"$ifNull": bson.A{"$weight", 1}},
+		}}},
+		{{Key: "$setWindowFields", Value: bson.M{
+			"partitionBy": group,
+			"sortBy":      bson.M{"duration": 1},
+			"output": bson.M{
+				"running": bson.M{"$sum": "$weight", "window": bson.M{"documents": bson.A{"unbounded", "current"}}},
+				"total":   bson.M{"$sum": "$weight", "window": bson.M{"documents": bson.A{"unbounded", "unbounded"}}},
+			},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    group,
+			"count":  bson.M{"$sum": "$weight"},
+			"errors": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gte": bson.A{"$status", 500}}, "$weight", 0}}},
+			"p50":    percentile(0.5),
+			"p95":    percentile(0.95),
+			"p99":    percentile(0.99),
+		}}},
+	}
+
+	// Partitions sorted for the running weights may outgrow the stage's memory limit
+	cursor, err := r.collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if isUnknownStage(err) {
+		return r.summarizeInGo(ctx, match, query)
+	}
+	if err != nil {
+		return nil, mapError(err, "summarize profiling records")
+	}
+	defer cursor.Close(ctx)
+
+	var rows []summaryRow
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, mapError(err, "decode profiling summary")
+	}
+
+	stats := make([]models.RouteStats, 0, len(rows))
+	for _, row := range rows {
+		s := models.RouteStats{
+			WindowStart: query.From.Add(time.Duration(row.ID.Window) * window).UTC(),
+			Method:      row.ID.Method,
+			Route:       row.ID.Route,
+			Count:       int64(math.Round(row.Count)),
+			Errors:      int64(math.Round(row.Errors)),
+			ErrorRate:   row.Errors / row.Count,
+			P50:         row.P50,
+			P95:         row.P95,
+			P99:         row.P99,
+		}
+		stats = append(stats, s)
+	}
+
+	models.SortRouteStats(stats)
+	return stats, nil
+}
+
+// summarizeInGo streams the matching records into a summary, reading no more than
+// maxSummarizedInGo of them so a wide range cannot exhaust memory
+func (r *ProfilingRepository) summarizeInGo(ctx context.Context, match bson.M, query models.ProfilingQuery) ([]models.RouteStats, error) {
+	count, err := r.collection.CountDocuments(ctx, match)
+	if err != nil {
+		return nil, mapError(err, "count profiling records")
+	}
+	if count > maxSummarizedInGo {
+		return nil, errs.Validation("the range holds %d profiling records, more than the %d MongoDB before 5.0 can summarize; narrow it", count, maxSummarizedInGo)
+	}
+
+	opts := options.Find().SetProjection(bson.M{"_id": 0, "method": 1, "route": 1, "status": 1, "duration": 1, "timestamp": 1, "weight": 1})
+	cursor, err := r.collection.Find(ctx, match, opts)
+	if err != nil {
+		return nil, mapError(err, "query profiling records")
+	}
+	defer cursor.Close(ctx)
+
+	summarizer := models.NewProfilingSummarizer(query)
+	for cursor.Next(ctx) {
+		var p models.Profiling
+		if err := cursor.Decode(&p); err != nil {
+			return nil, mapError(err, "decode profiling record")
+		}
+		summarizer.Add(p)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, mapError(err, "iterate profiling records")
+	}
+	return summarizer.Stats(), nil
+}
 
-	_, err := r.collection.InsertOne(ctx, profiling)
-	return err
+// isUnknownStage reports a server that does not know an aggregation stage
+func isUnknownStage(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) &&
+		(serverErr.HasErrorCode(unknownPipelineStage) || serverErr.HasErrorMessage("$setWindowFields"))
 }
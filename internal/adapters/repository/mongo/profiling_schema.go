@@ -0,0 +1,132 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const profilingCollection = "profiling"
+
+// profilingTimestampIndex doubles as the TTL index when retention is configured,
+// since MongoDB allows only one index per key pattern
+const profilingTimestampIndex = "timestamp_1"
+
+// profilingValidator rejects records missing the fields analytics rely on.
+// Extra fields stay allowed so the record can grow without a schema change
+var profilingValidator = bson.M{
+	"$jsonSchema": bson.M{
+		"bsonType": "object",
+		"required": bson.A{"apicall", "duration", "timestamp"},
+		"properties": bson.M{
+			"apicall":       bson.M{"bsonType": "string"},
+			"method":        bson.M{"bsonType": "string"},
+			"route":         bson.M{"bsonType": "string"},
+			"path_params":   bson.M{"bsonType": "object"},
+			"status":        bson.M{"bsonType": bson.A{"int", "long"}},
+			"response_size": bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+			"request_id":    bson.M{"bsonType": "string"},
+			"duration":      bson.M{"bsonType": bson.A{"int", "long"}, "minimum": 0},
+			"timestamp":     bson.M{"bsonType": "date"},
+			"weight":        bson.M{"bsonType": bson.A{"double", "int", "long"}, "minimum": 0, "exclusiveMinimum": true},
+		},
+	},
+}
+
+// EnsureProfilingCollection creates the profiling collection with its validator and
+// indexes, or brings an existing one in line. A ttl of zero keeps records forever.
+// Running it again with the same settings changes nothing
+func EnsureProfilingCollection(ctx context.Context, db *mongo.Database, ttl time.Duration) error {
+	// Truncating would turn a sub-second ttl into 0, which expires every record
+	if ttl != 0 && (ttl < time.Second || ttl%time.Second != 0 || ttl/time.Second > math.MaxInt32) {
+		return fmt.Errorf("profiling ttl %s is not a whole number of seconds from 1s to %d", ttl, math.MaxInt32)
+	}
+	names, err := db.ListCollectionNames(ctx, bson.M{"name": profilingCollection})
+	if err != nil {
+		return mapError(err, "list collections")
+	}
+
+	if len(names) == 0 {
+		err = db.CreateCollection(ctx, profilingCollection, options.CreateCollection().SetValidator(profilingValidator))
+		// Another instance may have created it in the meantime
+		if err != nil && !isNamespaceExists(err) {
+			return mapError(err, "create profiling collection")
+		}
+	}
+
+	// collMod also upgrades the validator of collections created by older versions
+	err = db.RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: profilingCollection},
+		{Key: "validator", Value: profilingValidator},
+	}).Err()
+	if err != nil {
+		return mapError(err, "set profiling validator")
+	}
+
+	collection := db.Collection(profilingCollection)
+
+	// Documents from before routes were recorded hold their duration in milliseconds.
+	// Giving them a route marks them converted, so this runs once
+	_, err = collection.UpdateMany(ctx,
+		bson.M{"route": bson.M{"$exists": false}},
+		bson.M{
+			"$mul": bson.M{"duration": 1000},
+			"$set": bson.M{"method": "", "route": "", "status": 0, "response_size": 0},
+		})
+	if err != nil {
+		return mapError(err, "convert profiling durations")
+	}
+
+	timestampOptions := options.Index().SetName(profilingTimestampIndex)
+	if ttl > 0 {
+		timestampOptions.SetExpireAfterSeconds(int32(ttl / time.Second))
+	}
+	if err := replaceChangedIndex(ctx, collection, profilingTimestampIndex, ttl); err != nil {
+		return err
+	}
+
+	_, err = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "timestamp", Value: 1}}, Options: timestampOptions},
+		{Keys: bson.D{{Key: "apicall", Value: 1}, {Key: "timestamp", Value: 1}}, Options: options.Index().SetName("apicall_timestamp")},
+		// Records without a request ID omit the field, so a sparse index skips them
+		{Keys: bson.D{{Key: "request_id", Value: 1}}, Options: options.Index().SetName("request_id").SetSparse(true)},
+	})
+	return mapError(err, "create profiling indexes")
+}
+
+// replaceChangedIndex drops the timestamp index when its retention differs from ttl,
+// so it can be recreated with the new setting
+func replaceChangedIndex(ctx context.Context, collection *mongo.Collection, name string, ttl time.Duration) error {
+	specs, err := collection.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		return mapError(err, "list profiling indexes")
+	}
+
+	for _, spec := range specs {
+		if spec.Name != name {
+			continue
+		}
+		var current time.Duration
+		if spec.ExpireAfterSeconds != nil {
+			current = time.Duration(*spec.ExpireAfterSeconds) * time.Second
+		}
+		if current == ttl {
+			return nil
+		}
+		_, err := collection.Indexes().DropOne(ctx, name)
+		return mapError(err, "drop profiling index "+name)
+	}
+	return nil
+}
+
+// isNamespaceExists reports the server error for creating a collection that already exists
+func isNamespaceExists(err error) bool {
+	var serverErr mongo.ServerError
+	return errors.As(err, &serverErr) && serverErr.HasErrorCode(namespaceExists)
+}
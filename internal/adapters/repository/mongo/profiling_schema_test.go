@@ -0,0 +1,68 @@
+package mongo
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+)
+
+func timestampTTL(t *testing.T, db *mongoDriver.Database) *int32 {
+	specs, err := db.Collection(profilingCollection).Indexes().ListSpecifications(context.Background())
+	require.NoError(t, err)
+	for _, spec := range specs {
+		if spec.Name == profilingTimestampIndex {
+			return spec.ExpireAfterSeconds
+		}
+	}
+	t.Fatalf("index %s not found", profilingTimestampIndex)
+	return nil
+}
+
+func TestEnsureProfilingCollection(t *testing.T) {
+	db := testDatabase(t)
+	ctx := context.Background()
+
+	// A document from before durations were in microseconds, converted once
+	_, err := db.Collection(profilingCollection).InsertOne(ctx, bson.M{"apicall": "GET /products", "duration": 12, "timestamp": time.Now()})
+	require.NoError(t, err)
+
+	require.NoError(t, EnsureProfilingCollection(ctx, db, 0))
+	require.NoError(t, EnsureProfilingCollection(ctx, db, 0))
+	assert.Nil(t, timestampTTL(t, db))
+	var converted struct {
+		Duration int64 `bson:"duration"`
+	}
+	require.NoError(t, db.Collection(profilingCollection).FindOne(ctx, bson.M{"apicall": "GET /products"}).Decode(&converted))
+	assert.Equal(t, int64(12000), converted.Duration)
+
+	require.NoError(t, EnsureProfilingCollection(ctx, db, time.Hour))
+	ttl := timestampTTL(t, db)
+	require.NotNil(t, ttl)
+	assert.Equal(t, int32(3600), *ttl)
+
+	require.NoError(t, EnsureProfilingCollection(ctx, db, 0))
+	assert.Nil(t, timestampTTL(t, db))
+
+	repo := NewProfilingRepository(db)
+	err = repo.CreateMany(ctx, []models.Profiling{{ID: uuid.New(), APICall: "GET /products", Duration: 12, Timestamp: time.Now()}})
+	assert.NoError(t, err)
+
+	_, err = db.Collection(profilingCollection).InsertOne(ctx, bson.M{"apicall": 42})
+	assert.ErrorIs(t, mapError(err, "insert"), errs.ErrValidation)
+}
+
+func TestEnsureProfilingCollectionRejectsTTL(t *testing.T) {
+	// Rejected before the database is touched
+	for _, ttl := range []time.Duration{500 * time.Millisecond, 1500 * time.Millisecond, (math.MaxInt32 + 1) * time.Second} {
+		assert.Error(t, EnsureProfilingCollection(context.Background(), nil, ttl), ttl)
+	}
+}
@@ -0,0 +1,62 @@
+package mongo
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/adapters/repository/repotest"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testDatabase connects to MONGO_TEST_URI and returns a throwaway database.
+// The URI must point at a replica set for the transaction tests
+func testDatabase(t *testing.T) *mongoDriver.Database {
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongoDriver.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	require.NoError(t, client.Ping(ctx, nil))
+
+	db := client.Database("hexa_test_" + uuid.NewString()[:8])
+	require.NoError(t, EnsureIndexes(ctx, db))
+	t.Cleanup(func() {
+		db.Drop(ctx)
+		client.Disconnect(ctx)
+	})
+	return db
+}
+
+func TestRepositoryConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		db := testDatabase(t)
+		return repotest.Repositories{
+			Products:   NewProductRepository(db),
+			Movements:  NewStockMovementRepository(db),
+			Transactor: NewTransactor(db),
+		}
+	})
+}
+
+func TestSupportsTransactions(t *testing.T) {
+	db := testDatabase(t)
+	supported, err := SupportsTransactions(context.Background(), db)
+	require.NoError(t, err)
+	require.True(t, supported, "MONGO_TEST_URI must point at a replica set")
+}
+
+func TestProfilingConformance(t *testing.T) {
+	repotest.RunProfiling(t, func(t *testing.T) ports.IProfilingRepository {
+		db := testDatabase(t)
+		require.NoError(t, EnsureProfilingCollection(context.Background(), db, 0))
+		return NewProfilingRepository(db)
+	})
+}
@@ -0,0 +1,141 @@
+package mongo
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type movementDocument struct {
+	ID        uuid.UUID `bson:"_id"`
+	ProductID uuid.UUID `bson:"product_id"`
+	// Seq orders a product's movements; timestamps alone can tie
+	Seq       int64     `bson:"seq"`
+	Delta     int       `bson:"delta"`
+	Balance   int       `bson:"balance"`
+	Reason    string    `bson:"reason"`
+	Actor     string    `bson:"actor"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+func (d movementDocument) toModel() models.StockMovement {
+	return models.StockMovement{
+		ID:        d.ID,
+		ProductID: d.ProductID,
+		Delta:     d.Delta,
+		Balance:   d.Balance,
+		Reason:    d.Reason,
+		Actor:     d.Actor,
+		CreatedAt: d.CreatedAt,
+	}
+}
+
+type StockMovementRepository struct {
+	collection *mongo.Collection
+	counters   *mongo.Collection
+}
+
+func NewStockMovementRepository(db *mongo.Database) ports.IStockMovementRepository {
+	return &StockMovementRepository{
+		collection: db.Collection("stock_movements"),
+		counters:   db.Collection("stock_movement_counters"),
+	}
+}
+
+func (r *StockMovementRepository) Append(ctx context.Context, m models.StockMovement) error {
+	// One counter per product, so concurrent writes to different products do not conflict
+	// It also keeps the latest time handed out, which the next movement may not precede
+	var counter struct {
+		Seq       int64     `bson:"seq"`
+		CreatedAt time.Time `bson:"created_at"`
+	}
+	err := r.counters.FindOneAndUpdate(ctx,
+		bson.M{"_id": m.ProductID},
+		bson.M{"$inc": bson.M{"seq": 1}, "$max": bson.M{"created_at": m.CreatedAt}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&counter)
+	if err != nil {
+		return mapError(err, "next stock movement sequence")
+	}
+
+	_, err = r.collection.InsertOne(ctx, movementDocument{
+		ID:        m.ID,
+		ProductID: m.ProductID,
+		Seq:       counter.Seq,
+		Delta:     m.Delta,
+		Balance:   m.Balance,
+		Reason:    m.Reason,
+		Actor:     m.Actor,
+		CreatedAt: counter.CreatedAt,
+	})
+	return mapError(err, "insert stock movement")
+}
+
+func (r *StockMovementRepository) List(ctx context.Context, query models.MovementQuery) (models.MovementPage, error) {
+	var page models.MovementPage
+
+	filter := bson.M{"product_id": query.ProductID}
+	createdAt := bson.M{}
+	if query.From != nil {
+		createdAt["$gte"] = *query.From
+	}
+	if query.To != nil {
+		createdAt["$lte"] = *query.To
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return page, mapError(err, "count stock movements")
+	}
+	page.Total = total
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "seq", Value: -1}}).
+		SetSkip(int64(query.Offset)).
+		SetLimit(int64(query.Limit + 1))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return page, mapError(err, "query stock movements")
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc movementDocument
+		if err := cursor.Decode(&doc); err != nil {
+			return page, mapError(err, "decode stock movement")
+		}
+		page.Items = append(page.Items, doc.toModel())
+	}
+	if err := cursor.Err(); err != nil {
+		return page, mapError(err, "iterate stock movements")
+	}
+
+	if len(page.Items) > query.Limit {
+		page.Items = page.Items[:query.Limit]
+		page.HasMore = true
+	}
+	return page, nil
+}
+
+func (r *StockMovementRepository) BalanceAt(ctx context.Context, productID uuid.UUID, at time.Time) (int, error) {
+	var doc movementDocument
+	err := r.collection.FindOne(ctx,
+		bson.M{"product_id": productID, "created_at": bson.M{"$lte": at}},
+		options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}}),
+	).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	return doc.Balance, mapError(err, "query stock balance")
+}
@@ -0,0 +1,63 @@
+package mongo
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Transactor runs work inside a multi-document transaction, which needs a replica set
+// or sharded cluster; a standalone server rejects it
+type Transactor struct {
+	client *mongo.Client
+}
+
+func NewTransactor(db *mongo.Database) ports.ITransactor {
+	return &Transactor{client: db.Client()}
+}
+
+// SupportsTransactions reports whether db is served by a replica set member or a
+// mongos, and so can run the Transactor's transactions
+func SupportsTransactions(ctx context.Context, db *mongo.Database) (bool, error) {
+	var reply struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	admin := db.Client().Database("admin")
+	err := admin.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&reply)
+	// Servers before 4.4.2 only know the older name
+	var serverErr mongo.ServerError
+	if errors.As(err, &serverErr) && serverErr.HasErrorCode(commandNotFound) {
+		err = admin.RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&reply)
+	}
+	if err != nil {
+		return false, mapError(err, "check deployment")
+	}
+	return reply.SetName != "" || reply.Msg == "isdbgrid", nil
+}
+
+func (t *Transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Nested calls join the outer transaction
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := t.client.StartSession()
+	if err != nil {
+		return mapError(err, "start session")
+	}
+	defer session.EndSession(ctx)
+
+	var fnErr error
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		fnErr = fn(sessCtx)
+		return nil, fnErr
+	})
+	if err != nil && err == fnErr {
+		return err
+	}
+	return mapError(err, "commit transaction")
+}
@@ -0,0 +1,53 @@
+package postgresql
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+
+	"github.com/lib/pq"
+)
+
+// mapError translates database/sql and lib/pq errors into domain errors
+func mapError(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	// Checked first, drivers also wrap context errors in their own error types
+	switch {
+	case errors.Is(err, context.Canceled):
+		return errs.Wrap(errs.KindCanceled, err, message)
+	case errors.Is(err, context.DeadlineExceeded):
+		return errs.Wrap(errs.KindTimeout, err, message)
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return errs.Wrap(errs.KindNotFound, err, message)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code == "23505", pqErr.Code == "23503", pqErr.Code.Class() == "40":
+			// unique/foreign key violation, serialization failure or deadlock
+			return errs.Wrap(errs.KindConflict, err, message)
+		case pqErr.Code.Class() == "22", pqErr.Code.Class() == "23":
+			// data exception or remaining integrity constraint violations
+			return errs.Wrap(errs.KindValidation, err, message)
+		case pqErr.Code.Class() == "08", pqErr.Code.Class() == "53", pqErr.Code.Class() == "57":
+			// connection exception, insufficient resources or operator intervention
+			return errs.Wrap(errs.KindUnavailable, err, message)
+		}
+		return errs.Wrap(errs.KindInternal, err, message)
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.As(err, &netErr) {
+		return errs.Wrap(errs.KindUnavailable, err, message)
+	}
+
+	return errs.Wrap(errs.KindInternal, err, message)
+}
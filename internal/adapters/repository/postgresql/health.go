@@ -0,0 +1,20 @@
+package postgresql
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"database/sql"
+)
+
+type HealthChecker struct {
+	db *sql.DB
+}
+
+// NewHealthChecker checks that the pool can hand out a connection that answers
+func NewHealthChecker(db *sql.DB) ports.IHealthChecker {
+	return &HealthChecker{db: db}
+}
+
+func (c *HealthChecker) Check(ctx context.Context) error {
+	return mapError(c.db.PingContext(ctx), "ping PostgreSQL")
+}
@@ -0,0 +1,79 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("orders by version", func(t *testing.T) {
+		migrations, err := loadMigrations(fstest.MapFS{
+			"0010_second.up.sql":   {Data: []byte("up 10")},
+			"0010_second.down.sql": {Data: []byte("down 10")},
+			"0002_first.up.sql":    {Data: []byte("up 2")},
+			"0002_first.down.sql":  {Data: []byte("down 2")},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []Migration{
+			{Version: 2, Name: "first", Up: "up 2", Down: "down 2"},
+			{Version: 10, Name: "second", Up: "up 10", Down: "down 10"},
+		}, migrations)
+	})
+
+	t.Run("requires both directions", func(t *testing.T) {
+		_, err := loadMigrations(fstest.MapFS{"0001_first.up.sql": {Data: []byte("up")}})
+		assert.ErrorContains(t, err, "both an up and a down")
+	})
+
+	t.Run("rejects conflicting names", func(t *testing.T) {
+		_, err := loadMigrations(fstest.MapFS{
+			"0001_first.up.sql":   {Data: []byte("up")},
+			"0001_other.down.sql": {Data: []byte("down")},
+		})
+		assert.ErrorContains(t, err, "two names")
+	})
+
+	t.Run("rejects bad names", func(t *testing.T) {
+		_, err := loadMigrations(fstest.MapFS{"first.sql": {Data: []byte("up")}})
+		assert.ErrorContains(t, err, "name must look like")
+	})
+
+	t.Run("embedded migrations are valid", func(t *testing.T) {
+		migrator, err := NewMigrator(nil)
+		require.NoError(t, err)
+		require.NotEmpty(t, migrator.migrations)
+		assert.Equal(t, int64(1), migrator.migrations[0].Version)
+	})
+}
+
+func TestMigrator(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	migrator, err := NewMigrator(db)
+	require.NoError(t, err)
+
+	pending, err := migrator.Pending(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	reverted, err := migrator.Down(ctx, len(migrator.migrations))
+	require.NoError(t, err)
+	assert.Len(t, reverted, len(migrator.migrations))
+
+	pending, err = migrator.Pending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, migrator.migrations, pending)
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, migrator.migrations, applied)
+
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+}
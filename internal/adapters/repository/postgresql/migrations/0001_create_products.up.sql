@@ -0,0 +1,6 @@
+-- IF NOT EXISTS adopts databases whose products table was created by hand
+CREATE TABLE IF NOT EXISTS products (
+    id    UUID PRIMARY KEY,
+    name  TEXT NOT NULL,
+    stock INTEGER NOT NULL
+);
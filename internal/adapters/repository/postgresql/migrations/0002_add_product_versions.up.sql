@@ -0,0 +1,3 @@
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS version    BIGINT      NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
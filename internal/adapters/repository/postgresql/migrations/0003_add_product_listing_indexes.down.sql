@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS products_stock_id_idx;
+DROP INDEX IF EXISTS products_name_id_idx;
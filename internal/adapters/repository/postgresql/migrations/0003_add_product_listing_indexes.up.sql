@@ -0,0 +1,3 @@
+-- Keyset pagination orders by (column, id)
+CREATE INDEX IF NOT EXISTS products_name_id_idx ON products (name, id);
+CREATE INDEX IF NOT EXISTS products_stock_id_idx ON products (stock, id);
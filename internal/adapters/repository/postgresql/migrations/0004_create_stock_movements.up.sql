@@ -0,0 +1,12 @@
+-- No foreign key to products: the ledger outlives deleted products
+CREATE TABLE IF NOT EXISTS stock_movements (
+    seq        BIGSERIAL PRIMARY KEY,
+    id         UUID        NOT NULL UNIQUE,
+    product_id UUID        NOT NULL,
+    delta      INTEGER     NOT NULL,
+    balance    INTEGER     NOT NULL,
+    reason     TEXT        NOT NULL,
+    actor      TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS stock_movements_product_seq_idx ON stock_movements (product_id, seq);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS profiling (
+    id        UUID PRIMARY KEY,
+    api_call  TEXT        NOT NULL,
+    duration  BIGINT      NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS profiling_timestamp_idx ON profiling (timestamp);
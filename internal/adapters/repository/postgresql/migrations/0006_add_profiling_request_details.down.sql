@@ -0,0 +1,8 @@
+UPDATE profiling SET duration = duration / 1000;
+DROP INDEX IF EXISTS profiling_route_timestamp_idx;
+ALTER TABLE profiling
+    DROP COLUMN IF EXISTS response_size,
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS path_params,
+    DROP COLUMN IF EXISTS route,
+    DROP COLUMN IF EXISTS method;
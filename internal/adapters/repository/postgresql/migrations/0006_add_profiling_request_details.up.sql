@@ -0,0 +1,9 @@
+ALTER TABLE profiling
+    ADD COLUMN IF NOT EXISTS method        TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS route         TEXT    NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS path_params   JSONB,
+    ADD COLUMN IF NOT EXISTS status        INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS response_size BIGINT  NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS profiling_route_timestamp_idx ON profiling (method, route, timestamp);
+-- duration is now recorded in microseconds, the rows so far hold milliseconds
+UPDATE profiling SET duration = duration * 1000;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS profiling_request_id_idx;
+ALTER TABLE profiling DROP COLUMN IF EXISTS request_id;
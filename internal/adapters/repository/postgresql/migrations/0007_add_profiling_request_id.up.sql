@@ -0,0 +1,3 @@
+ALTER TABLE profiling ADD COLUMN IF NOT EXISTS request_id TEXT NOT NULL DEFAULT '';
+-- Only requests being investigated are looked up by ID, most records are never
+CREATE INDEX IF NOT EXISTS profiling_request_id_idx ON profiling (request_id) WHERE request_id <> '';
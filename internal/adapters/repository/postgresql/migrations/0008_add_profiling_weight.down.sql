@@ -0,0 +1 @@
+ALTER TABLE profiling DROP COLUMN IF EXISTS weight;
@@ -0,0 +1,2 @@
+-- Records written before sampling stand for one request each
+ALTER TABLE profiling ADD COLUMN IF NOT EXISTS weight DOUBLE PRECISION NOT NULL DEFAULT 1;
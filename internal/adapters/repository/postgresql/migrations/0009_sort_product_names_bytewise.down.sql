@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS products_name_id_idx;
+CREATE INDEX IF NOT EXISTS products_name_id_idx ON products (name, id);
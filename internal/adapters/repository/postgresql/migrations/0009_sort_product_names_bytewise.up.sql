@@ -0,0 +1,3 @@
+-- Names sort byte-wise, like the other adapters, whatever the database collation
+DROP INDEX IF EXISTS products_name_id_idx;
+CREATE INDEX IF NOT EXISTS products_name_id_idx ON products (name COLLATE "C", id);
@@ -1,9 +1,15 @@
 package postgresql
 
 import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
 	product "CRUD-Go-Hexa-MongoDB/internal/domain/models"
 	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
 	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	_ "github.com/lib/pq" // Import the PostgreSQL driver
@@ -17,41 +23,163 @@ func NewProductRepository(db *sql.DB) ports.IProductRepository {
 	return &ProductRepository{db: db}
 }
 
-func (r *ProductRepository) FindAll() ([]product.Product, error) {
-	rows, err := r.db.Query("SELECT id, name, stock FROM products")
+// sortColumns whitelists the columns a query may order by. Names sort byte-wise like
+// in the other adapters, not by the database collation, so pages and cursors agree
+var sortColumns = map[product.ProductSortField]string{
+	product.SortByName:  `name COLLATE "C"`,
+	product.SortByStock: "stock",
+}
+
+func (r *ProductRepository) FindAll(ctx context.Context, query product.ProductQuery) (product.ProductPage, error) {
+	var page product.ProductPage
+
+	column, ok := sortColumns[query.SortBy]
+	if !ok {
+		return page, errs.Validation("unsupported sort field %q", query.SortBy)
+	}
+
+	var conditions []string
+	var args []interface{}
+	addArg := func(value interface{}) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if query.NameContains != "" {
+		conditions = append(conditions, "name ILIKE '%' || "+addArg(escapeLike(query.NameContains))+" || '%' ESCAPE '\\'")
+	}
+	if query.MinStock != nil {
+		conditions = append(conditions, "stock >= "+addArg(*query.MinStock))
+	}
+	if query.MaxStock != nil {
+		conditions = append(conditions, "stock <= "+addArg(*query.MaxStock))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT count(*) FROM products"+where, args...).Scan(&page.Total)
 	if err != nil {
-		return nil, err
+		return page, mapError(err, "count products")
+	}
+
+	direction, comparison := "ASC", ">"
+	if query.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	if query.After != nil {
+		var after interface{} = query.After.Name
+		if query.SortBy == product.SortByStock {
+			after = query.After.Stock
+		}
+		conditions = append(conditions, "("+column+", id) "+comparison+" ("+addArg(after)+", "+addArg(query.After.ID)+")")
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	statement := "SELECT " + productColumns + " FROM products" + where +
+		" ORDER BY " + column + " " + direction + ", id " + direction +
+		" LIMIT " + addArg(query.Limit+1) + " OFFSET " + addArg(query.Offset)
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, statement, args...)
+	if err != nil {
+		return page, mapError(err, "query products")
 	}
 	defer rows.Close()
 
-	var products []product.Product
 	for rows.Next() {
-		var p product.Product
-		if err := rows.Scan(&p.ID, &p.Name, &p.Stock); err != nil {
-			return nil, err
+		p, err := scanProduct(rows)
+		if err != nil {
+			return page, mapError(err, "scan product")
 		}
-		products = append(products, p)
+		page.Items = append(page.Items, p)
+	}
+	if err := rows.Err(); err != nil {
+		return page, mapError(err, "iterate products")
 	}
-	return products, nil
+
+	if len(page.Items) > query.Limit {
+		page.Items = page.Items[:query.Limit]
+		page.HasMore = true
+	}
+	return page, nil
+}
+
+// escapeLike escapes the LIKE wildcards so user input is matched literally
+func escapeLike(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+}
+
+func (r *ProductRepository) FindByID(ctx context.Context, id uuid.UUID) (product.Product, error) {
+	product, err := scanProduct(conn(ctx, r.db).QueryRowContext(ctx, "SELECT "+productColumns+" FROM products WHERE id = $1", id))
+	return product, mapError(err, "find product "+id.String())
 }
 
-func (r *ProductRepository) FindByID(id uuid.UUID) (product.Product, error) {
-	var product product.Product
-	err := r.db.QueryRow("SELECT id, name, stock FROM products WHERE id = $1", id).Scan(&product.ID, &product.Name, &product.Stock)
-	return product, err
+func (r *ProductRepository) Create(ctx context.Context, product product.Product) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "INSERT INTO products (id, name, stock, version, updated_at) VALUES ($1, $2, $3, $4, $5)",
+		product.ID, product.Name, product.Stock, product.Version, product.UpdatedAt)
+	return mapError(err, "insert product")
 }
 
-func (r *ProductRepository) Create(product product.Product) error {
-	_, err := r.db.Exec("INSERT INTO products (id, name, stock) VALUES ($1, $2, $3)", product.ID, product.Name, product.Stock)
-	return err
+func (r *ProductRepository) Update(ctx context.Context, p product.Product) (product.Product, error) {
+	updated, err := scanProduct(conn(ctx, r.db).QueryRowContext(ctx,
+		"UPDATE products SET name = $1, stock = $2, version = version + 1, updated_at = $3 WHERE id = $4 AND version = $5 RETURNING "+productColumns,
+		p.Name, p.Stock, p.UpdatedAt, p.ID, p.Version))
+	if errors.Is(err, sql.ErrNoRows) {
+		return updated, r.notFoundOr(ctx, p.ID, errs.Conflict("product %s was modified concurrently", p.ID))
+	}
+	return updated, mapError(err, "update product "+p.ID.String())
+}
+
+func (r *ProductRepository) AdjustStock(ctx context.Context, id uuid.UUID, delta int, updatedAt time.Time) (product.Product, error) {
+	adjusted, err := scanProduct(conn(ctx, r.db).QueryRowContext(ctx,
+		"UPDATE products SET stock = stock + $1, version = version + 1, updated_at = $2 WHERE id = $3 AND stock + $1 >= 0 RETURNING "+productColumns,
+		delta, updatedAt, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return adjusted, r.notFoundOr(ctx, id, errs.Conflict("adjusting product %s by %d would make stock negative", id, delta))
+	}
+	return adjusted, mapError(err, "adjust stock of product "+id.String())
 }
 
-func (r *ProductRepository) Update(product product.Product) error {
-	_, err := r.db.Exec("UPDATE products SET name = $1, stock = $2 WHERE id = $3", product.Name, product.Stock, product.ID)
-	return err
+func (r *ProductRepository) Delete(ctx context.Context, id uuid.UUID, expectedVersion int64) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM products WHERE id = $1 AND ($2::bigint = 0 OR version = $2::bigint)", id, expectedVersion)
+	if err != nil {
+		return mapError(err, "delete product "+id.String())
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return mapError(err, "rows affected")
+	}
+	if affected == 0 {
+		return r.notFoundOr(ctx, id, errs.PreconditionFailed("product %s does not have version %d", id, expectedVersion))
+	}
+	return nil
+}
+
+// notFoundOr tells a missing product apart from a conditional statement that failed on an existing one
+func (r *ProductRepository) notFoundOr(ctx context.Context, id uuid.UUID, conditionErr error) error {
+	var exists bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM products WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		return mapError(err, "find product "+id.String())
+	}
+	if !exists {
+		return errs.NotFound("product %s not found", id)
+	}
+	return conditionErr
+}
+
+const productColumns = "id, name, stock, version, updated_at"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
-func (r *ProductRepository) Delete(id uuid.UUID) error {
-	_, err := r.db.Exec("DELETE FROM products WHERE id = $1", id)
-	return err
+func scanProduct(row rowScanner) (product.Product, error) {
+	var p product.Product
+	err := row.Scan(&p.ID, &p.Name, &p.Stock, &p.Version, &p.UpdatedAt)
+	return p, err
 }
@@ -0,0 +1,118 @@
+package postgresql
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/adapters/repository/sqlcodec"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"database/sql"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ProfilingRepository struct {
+	db *sql.DB
+}
+
+func NewProfilingRepository(db *sql.DB) ports.IProfilingRepository {
+	return &ProfilingRepository{db: db}
+}
+
+// profilingColumns is the number of values inserted per record
+const profilingColumns = 11
+
+func (r *ProfilingRepository) CreateMany(ctx context.Context, records []models.Profiling) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var statement strings.Builder
+	statement.WriteString("INSERT INTO profiling (id, api_call, method, route, path_params, status, response_size, request_id, duration, timestamp, weight) VALUES ")
+	args := make([]interface{}, 0, len(records)*profilingColumns)
+
+	for i, record := range records {
+		pathParams, err := sqlcodec.PathParams(record.PathParams)
+		if err != nil {
+			return mapError(err, "encode path params")
+		}
+
+		if i > 0 {
+			statement.WriteString(", ")
+		}
+		statement.WriteString("(")
+		for column := 1; column <= profilingColumns; column++ {
+			if column > 1 {
+				statement.WriteString(", ")
+			}
+			statement.WriteString("$" + strconv.Itoa(len(args)+column))
+		}
+		statement.WriteString(")")
+
+		args = append(args, record.ID, record.APICall, record.Method, record.Route, pathParams,
+			record.Status, record.ResponseSize, record.RequestID, record.Duration, record.Timestamp, record.SampleWeight())
+	}
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, statement.String(), args...)
+	return mapError(err, "insert profiling records")
+}
+
+func (r *ProfilingRepository) Summarize(ctx context.Context, query models.ProfilingQuery) ([]models.RouteStats, error) {
+	// A window longer than the range puts everything in window 0
+	window := query.Window
+	if window <= 0 {
+		window = query.To.Sub(query.From) + time.Second
+	}
+
+	args := []interface{}{query.From, query.To, window.Seconds()}
+	where := "timestamp >= $1 AND timestamp < $2"
+	if query.Method != "" {
+		args = append(args, query.Method)
+		where += " AND method = $" + strconv.Itoa(len(args))
+	}
+	if query.Route != "" {
+		args = append(args, query.Route)
+		where += " AND route = $" + strconv.Itoa(len(args))
+	}
+
+	// Weighted nearest-rank percentiles, like models.ProfilingSummarizer: the first
+	// duration whose running weight reaches the share of the group's total weight.
+	// The slack absorbs the rounding of summed fractional weights
+	rows, err := conn(ctx, r.db).QueryContext(ctx,
+		"SELECT window_index, method, route, sum(weight), coalesce(sum(weight) FILTER (WHERE status >= 500), 0),"+
+			" min(duration) FILTER (WHERE running >= 0.5 * total - 1e-9),"+
+			" min(duration) FILTER (WHERE running >= 0.95 * total - 1e-9),"+
+			" min(duration) FILTER (WHERE running >= 0.99 * total - 1e-9)"+
+			" FROM (SELECT window_index, method, route, status, duration, weight,"+
+			" sum(weight) OVER (PARTITION BY window_index, method, route ORDER BY duration ROWS UNBOUNDED PRECEDING) AS running,"+
+			" sum(weight) OVER (PARTITION BY window_index, method, route) AS total"+
+			" FROM (SELECT floor(extract(epoch FROM timestamp - $1::timestamptz) / $3::float8)::bigint AS window_index,"+
+			" method, route, status, duration, weight FROM profiling WHERE "+where+") AS records) AS ranked"+
+			" GROUP BY window_index, method, route", args...)
+	if err != nil {
+		return nil, mapError(err, "summarize profiling records")
+	}
+	defer rows.Close()
+
+	var stats []models.RouteStats
+	for rows.Next() {
+		var s models.RouteStats
+		var windowIndex int64
+		var total, errors float64
+		if err := rows.Scan(&windowIndex, &s.Method, &s.Route, &total, &errors, &s.P50, &s.P95, &s.P99); err != nil {
+			return nil, mapError(err, "scan profiling summary")
+		}
+		s.WindowStart = query.From.Add(time.Duration(windowIndex) * window).UTC()
+		s.Count = int64(math.Round(total))
+		s.Errors = int64(math.Round(errors))
+		s.ErrorRate = errors / total
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err, "iterate profiling summary")
+	}
+
+	models.SortRouteStats(stats)
+	return stats, nil
+}
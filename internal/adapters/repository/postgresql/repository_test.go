@@ -0,0 +1,50 @@
+package postgresql
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/adapters/repository/repotest"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testDB connects to POSTGRES_TEST_DSN, migrates it and empties the tables before each test
+func testDB(t *testing.T) *sql.DB {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+
+	ctx := context.Background()
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := NewMigrator(db)
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "TRUNCATE products, stock_movements, profiling")
+	require.NoError(t, err)
+	return db
+}
+
+func TestRepositoryConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		db := testDB(t)
+		return repotest.Repositories{
+			Products:   NewProductRepository(db),
+			Movements:  NewStockMovementRepository(db),
+			Transactor: NewTransactor(db),
+		}
+	})
+}
+
+func TestProfilingConformance(t *testing.T) {
+	repotest.RunProfiling(t, func(t *testing.T) ports.IProfilingRepository {
+		return NewProfilingRepository(testDB(t))
+	})
+}
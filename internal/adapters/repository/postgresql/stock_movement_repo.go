@@ -0,0 +1,92 @@
+package postgresql
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StockMovementRepository struct {
+	db *sql.DB
+}
+
+func NewStockMovementRepository(db *sql.DB) ports.IStockMovementRepository {
+	return &StockMovementRepository{db: db}
+}
+
+func (r *StockMovementRepository) Append(ctx context.Context, m models.StockMovement) error {
+	// GREATEST ignores the NULL of a product's first movement
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		"INSERT INTO stock_movements (id, product_id, delta, balance, reason, actor, created_at) VALUES ($1, $2, $3, $4, $5, $6, "+
+			"GREATEST($7, (SELECT created_at FROM stock_movements WHERE product_id = $2 ORDER BY seq DESC LIMIT 1)))",
+		m.ID, m.ProductID, m.Delta, m.Balance, m.Reason, m.Actor, m.CreatedAt)
+	return mapError(err, "insert stock movement")
+}
+
+func (r *StockMovementRepository) List(ctx context.Context, query models.MovementQuery) (models.MovementPage, error) {
+	var page models.MovementPage
+
+	conditions := []string{"product_id = $1"}
+	args := []interface{}{query.ProductID}
+	addArg := func(value interface{}) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if query.From != nil {
+		conditions = append(conditions, "created_at >= "+addArg(*query.From))
+	}
+	if query.To != nil {
+		conditions = append(conditions, "created_at <= "+addArg(*query.To))
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT count(*) FROM stock_movements"+where, args...).Scan(&page.Total)
+	if err != nil {
+		return page, mapError(err, "count stock movements")
+	}
+
+	// seq follows commit order per product because the product row lock serializes its movements
+	rows, err := conn(ctx, r.db).QueryContext(ctx,
+		"SELECT id, product_id, delta, balance, reason, actor, created_at FROM stock_movements"+where+
+			" ORDER BY seq DESC LIMIT "+addArg(query.Limit+1)+" OFFSET "+addArg(query.Offset), args...)
+	if err != nil {
+		return page, mapError(err, "query stock movements")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m models.StockMovement
+		if err := rows.Scan(&m.ID, &m.ProductID, &m.Delta, &m.Balance, &m.Reason, &m.Actor, &m.CreatedAt); err != nil {
+			return page, mapError(err, "scan stock movement")
+		}
+		page.Items = append(page.Items, m)
+	}
+	if err := rows.Err(); err != nil {
+		return page, mapError(err, "iterate stock movements")
+	}
+
+	if len(page.Items) > query.Limit {
+		page.Items = page.Items[:query.Limit]
+		page.HasMore = true
+	}
+	return page, nil
+}
+
+func (r *StockMovementRepository) BalanceAt(ctx context.Context, productID uuid.UUID, at time.Time) (int, error) {
+	var balance int
+	err := conn(ctx, r.db).QueryRowContext(ctx,
+		"SELECT balance FROM stock_movements WHERE product_id = $1 AND created_at <= $2 ORDER BY seq DESC LIMIT 1",
+		productID, at).Scan(&balance)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return balance, mapError(err, "query stock balance")
+}
@@ -0,0 +1,132 @@
+package repotest
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ProfilingFactory returns a profiling repository backed by an empty store
+type ProfilingFactory func(t *testing.T) ports.IProfilingRepository
+
+// RunProfiling runs the profiling repository contract against the repositories returned by newRepository
+func RunProfiling(t *testing.T, newRepository ProfilingFactory) {
+	t.Run("Summarize", func(t *testing.T) { testSummarize(t, newRepository(t)) })
+	t.Run("SummarizeWindows", func(t *testing.T) { testSummarizeWindows(t, newRepository(t)) })
+	t.Run("SummarizeWeights", func(t *testing.T) { testSummarizeWeights(t, newRepository(t)) })
+}
+
+func profilingRecord(method, route string, status int, duration int64, at time.Time) models.Profiling {
+	return models.Profiling{
+		ID:        uuid.New(),
+		APICall:   method + " " + route,
+		Method:    method,
+		Route:     route,
+		Status:    status,
+		Duration:  duration,
+		Timestamp: at,
+	}
+}
+
+func testSummarize(t *testing.T, repo ports.IProfilingRepository) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var records []models.Profiling
+	for i := 1; i <= 100; i++ {
+		status := 200
+		if i%10 == 0 {
+			status = 500
+		}
+		records = append(records, profilingRecord("GET", "/products/:id", status, int64(i), start.Add(time.Duration(i)*time.Second)))
+	}
+	records = append(records,
+		profilingRecord("POST", "/products", 201, 7, start),
+		// Outside the range on both ends
+		profilingRecord("POST", "/products", 201, 7, start.Add(-time.Second)),
+		profilingRecord("POST", "/products", 201, 7, start.Add(time.Hour)),
+	)
+	require.NoError(t, repo.CreateMany(ctx, records))
+
+	stats, err := repo.Summarize(ctx, models.ProfilingQuery{From: start, To: start.Add(time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+
+	products := stats[0]
+	assert.Equal(t, "/products", products.Route)
+	assert.Equal(t, int64(1), products.Count)
+	assert.Equal(t, int64(7), products.P99)
+
+	byID := stats[1]
+	assert.True(t, start.Equal(byID.WindowStart))
+	assert.Equal(t, "GET", byID.Method)
+	assert.Equal(t, "/products/:id", byID.Route)
+	assert.Equal(t, int64(100), byID.Count)
+	assert.Equal(t, int64(10), byID.Errors)
+	assert.InDelta(t, 0.1, byID.ErrorRate, 1e-9)
+	// Stores may approximate percentiles, so allow a little slack
+	assert.InDelta(t, 50, byID.P50, 1)
+	assert.InDelta(t, 95, byID.P95, 1)
+	assert.InDelta(t, 99, byID.P99, 1)
+
+	stats, err = repo.Summarize(ctx, models.ProfilingQuery{From: start, To: start.Add(time.Hour), Method: "POST", Route: "/products"})
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, "POST", stats[0].Method)
+}
+
+func testSummarizeWindows(t *testing.T, repo ports.IProfilingRepository) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, repo.CreateMany(ctx, []models.Profiling{
+		profilingRecord("GET", "/products", 200, 10, start.Add(time.Minute)),
+		profilingRecord("GET", "/products", 200, 20, start.Add(4*time.Minute)),
+		profilingRecord("GET", "/products", 503, 30, start.Add(12*time.Minute)),
+	}))
+
+	stats, err := repo.Summarize(ctx, models.ProfilingQuery{From: start, To: start.Add(15 * time.Minute), Window: 5 * time.Minute})
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+
+	assert.True(t, start.Equal(stats[0].WindowStart), "got %v", stats[0].WindowStart)
+	assert.Equal(t, int64(2), stats[0].Count)
+	assert.Equal(t, int64(0), stats[0].Errors)
+
+	assert.True(t, start.Add(10*time.Minute).Equal(stats[1].WindowStart), "got %v", stats[1].WindowStart)
+	assert.Equal(t, int64(1), stats[1].Count)
+	assert.Equal(t, 1.0, stats[1].ErrorRate)
+	assert.Equal(t, int64(30), stats[1].P50)
+}
+
+func testSummarizeWeights(t *testing.T, repo ports.IProfilingRepository) {
+	ctx := context.Background()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Two fast requests sampled at 10%, and every failed one
+	var records []models.Profiling
+	for i, duration := range []int64{10, 20, 1000, 2000, 3000} {
+		record := profilingRecord("GET", "/products", 500, duration, start.Add(time.Duration(i)*time.Second))
+		if duration < 1000 {
+			record.Status, record.Weight = 200, 10
+		}
+		records = append(records, record)
+	}
+	require.NoError(t, repo.CreateMany(ctx, records))
+
+	stats, err := repo.Summarize(ctx, models.ProfilingQuery{From: start, To: start.Add(time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, int64(23), stats[0].Count)
+	assert.Equal(t, int64(3), stats[0].Errors)
+	assert.InDelta(t, 3.0/23, stats[0].ErrorRate, 1e-9)
+	// Unweighted, the failures would make up the median
+	assert.Equal(t, int64(20), stats[0].P50)
+	assert.Equal(t, int64(3000), stats[0].P99)
+}
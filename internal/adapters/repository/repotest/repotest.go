@@ -0,0 +1,472 @@
+// Package repotest is a backend-agnostic conformance suite for the repository ports.
+// Every adapter runs it against itself so they all honor the same contract
+package repotest
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Repositories are the adapters of one backend under test
+type Repositories struct {
+	Products   ports.IProductRepository
+	Movements  ports.IStockMovementRepository
+	Transactor ports.ITransactor
+}
+
+// Factory returns repositories backed by an empty store. It is called once per
+// test and should register any cleanup on t
+type Factory func(t *testing.T) Repositories
+
+// Run runs the whole suite against the repositories returned by newRepositories
+func Run(t *testing.T, newRepositories Factory) {
+	t.Run("RoundTrip", func(t *testing.T) { testRoundTrip(t, newRepositories(t)) })
+	t.Run("NotFound", func(t *testing.T) { testNotFound(t, newRepositories(t)) })
+	t.Run("Versioning", func(t *testing.T) { testVersioning(t, newRepositories(t)) })
+	t.Run("AdjustStock", func(t *testing.T) { testAdjustStock(t, newRepositories(t)) })
+	t.Run("ConcurrentAdjustStock", func(t *testing.T) { testConcurrentAdjustStock(t, newRepositories(t)) })
+	t.Run("ConcurrentUpdate", func(t *testing.T) { testConcurrentUpdate(t, newRepositories(t)) })
+	t.Run("Ordering", func(t *testing.T) { testOrdering(t, newRepositories(t)) })
+	t.Run("MixedCaseOrdering", func(t *testing.T) { testMixedCaseOrdering(t, newRepositories(t)) })
+	t.Run("Filtering", func(t *testing.T) { testFiltering(t, newRepositories(t)) })
+	t.Run("Movements", func(t *testing.T) { testMovements(t, newRepositories(t)) })
+	t.Run("SkewedMovements", func(t *testing.T) { testSkewedMovements(t, newRepositories(t)) })
+	t.Run("Transactions", func(t *testing.T) { testTransactions(t, newRepositories(t)) })
+}
+
+// NewProduct returns a product ready to be created. UpdatedAt is truncated to the
+// millisecond, the coarsest precision among the supported backends
+func NewProduct(name string, stock int) models.Product {
+	return models.Product{
+		ID:        uuid.New(),
+		Name:      name,
+		Stock:     stock,
+		Version:   1,
+		UpdatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+}
+
+func assertProduct(t *testing.T, want, got models.Product) {
+	t.Helper()
+	assert.True(t, want.UpdatedAt.Equal(got.UpdatedAt), "updated_at: want %v, got %v", want.UpdatedAt, got.UpdatedAt)
+	want.UpdatedAt, got.UpdatedAt = time.Time{}, time.Time{}
+	assert.Equal(t, want, got)
+}
+
+func names(products []models.Product) []string {
+	result := make([]string, 0, len(products))
+	for _, p := range products {
+		result = append(result, p.Name)
+	}
+	return result
+}
+
+func testRoundTrip(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	p := NewProduct("Round Trip", 5)
+	require.NoError(t, repos.Products.Create(ctx, p))
+
+	found, err := repos.Products.FindByID(ctx, p.ID)
+	require.NoError(t, err)
+	assertProduct(t, p, found)
+
+	err = repos.Products.Create(ctx, p)
+	assert.ErrorIs(t, err, errs.ErrConflict)
+
+	p.Name = "Renamed"
+	p.Stock = 7
+	p.UpdatedAt = p.UpdatedAt.Add(time.Second)
+	updated, err := repos.Products.Update(ctx, p)
+	require.NoError(t, err)
+	p.Version = 2
+	assertProduct(t, p, updated)
+
+	found, err = repos.Products.FindByID(ctx, p.ID)
+	require.NoError(t, err)
+	assertProduct(t, p, found)
+
+	require.NoError(t, repos.Products.Delete(ctx, p.ID, 0))
+	_, err = repos.Products.FindByID(ctx, p.ID)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+}
+
+func testNotFound(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	missing := NewProduct("Missing", 1)
+
+	_, err := repos.Products.FindByID(ctx, missing.ID)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	_, err = repos.Products.Update(ctx, missing)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	_, err = repos.Products.AdjustStock(ctx, missing.ID, 1, time.Now())
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	err = repos.Products.Delete(ctx, missing.ID, 0)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+
+	err = repos.Products.Delete(ctx, missing.ID, 1)
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+}
+
+func testVersioning(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	p := NewProduct("Versioned", 5)
+	require.NoError(t, repos.Products.Create(ctx, p))
+
+	updated, err := repos.Products.Update(ctx, p)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), updated.Version)
+
+	// p still carries version 1
+	_, err = repos.Products.Update(ctx, p)
+	assert.ErrorIs(t, err, errs.ErrConflict)
+
+	err = repos.Products.Delete(ctx, p.ID, 1)
+	assert.ErrorIs(t, err, errs.ErrPreconditionFailed)
+
+	require.NoError(t, repos.Products.Delete(ctx, p.ID, 2))
+}
+
+func testAdjustStock(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	p := NewProduct("Adjusted", 5)
+	require.NoError(t, repos.Products.Create(ctx, p))
+
+	at := p.UpdatedAt.Add(time.Minute)
+	adjusted, err := repos.Products.AdjustStock(ctx, p.ID, -5, at)
+	require.NoError(t, err)
+	assert.Equal(t, 0, adjusted.Stock)
+	assert.Equal(t, int64(2), adjusted.Version)
+	assert.True(t, at.Equal(adjusted.UpdatedAt))
+
+	_, err = repos.Products.AdjustStock(ctx, p.ID, -1, at)
+	assert.ErrorIs(t, err, errs.ErrConflict)
+
+	found, err := repos.Products.FindByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, found.Stock)
+	assert.Equal(t, int64(2), found.Version)
+}
+
+func testConcurrentAdjustStock(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	const workers = 10
+
+	p := NewProduct("Contended", 0)
+	require.NoError(t, repos.Products.Create(ctx, p))
+
+	var wg sync.WaitGroup
+	results := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repos.Products.AdjustStock(ctx, p.ID, 1, time.Now())
+			results <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	for err := range results {
+		assert.NoError(t, err)
+	}
+
+	found, err := repos.Products.FindByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, workers, found.Stock)
+	assert.Equal(t, int64(1+workers), found.Version)
+}
+
+func testConcurrentUpdate(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	const workers = 5
+
+	p := NewProduct("Raced", 1)
+	require.NoError(t, repos.Products.Create(ctx, p))
+
+	var wg sync.WaitGroup
+	results := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(stock int) {
+			defer wg.Done()
+			candidate := p
+			candidate.Stock = stock
+			_, err := repos.Products.Update(ctx, candidate)
+			results <- err
+		}(i + 10)
+	}
+	wg.Wait()
+	close(results)
+
+	// Every writer read version 1, so exactly one of them may win
+	var succeeded int
+	for err := range results {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, errs.ErrConflict)
+	}
+	assert.Equal(t, 1, succeeded)
+
+	found, err := repos.Products.FindByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), found.Version)
+}
+
+func testOrdering(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	for i, name := range []string{"Delta", "Alpha", "Charlie", "Bravo", "Echo"} {
+		require.NoError(t, repos.Products.Create(ctx, NewProduct(name, i%2)))
+	}
+
+	query := models.ProductQuery{Limit: 2, SortBy: models.SortByName}
+	var seen []string
+	for {
+		page, err := repos.Products.FindAll(ctx, query)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), page.Total)
+		seen = append(seen, names(page.Items)...)
+		if !page.HasMore {
+			break
+		}
+		cursor := models.CursorAfter(page.Items[len(page.Items)-1], query.SortBy, query.Descending)
+		query.After = &cursor
+	}
+	assert.Equal(t, []string{"Alpha", "Bravo", "Charlie", "Delta", "Echo"}, seen)
+
+	page, err := repos.Products.FindAll(ctx, models.ProductQuery{Limit: 2, Offset: 1, SortBy: models.SortByName, Descending: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Delta", "Charlie"}, names(page.Items))
+	assert.True(t, page.HasMore)
+
+	// Equal stock values fall back to ID order, so paging by stock neither skips nor repeats
+	query = models.ProductQuery{Limit: 1, SortBy: models.SortByStock, Descending: true}
+	seenIDs := map[uuid.UUID]bool{}
+	var stocks []int
+	for {
+		page, err := repos.Products.FindAll(ctx, query)
+		require.NoError(t, err)
+		for _, p := range page.Items {
+			assert.False(t, seenIDs[p.ID], "product %s returned twice", p.Name)
+			seenIDs[p.ID] = true
+			stocks = append(stocks, p.Stock)
+		}
+		if !page.HasMore {
+			break
+		}
+		cursor := models.CursorAfter(page.Items[len(page.Items)-1], query.SortBy, query.Descending)
+		query.After = &cursor
+	}
+	assert.Equal(t, []int{1, 1, 0, 0, 0}, stocks)
+
+	_, err = repos.Products.FindAll(ctx, models.ProductQuery{Limit: 2, SortBy: "price"})
+	assert.ErrorIs(t, err, errs.ErrValidation)
+}
+
+// Every adapter sorts names byte-wise, so a database collation that folds case or
+// accents cannot make pages or cursors differ between backends
+func testMixedCaseOrdering(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	for _, name := range []string{"banana", "Éclair", "apple", "Banana", "cherry", "Apple"} {
+		require.NoError(t, repos.Products.Create(ctx, NewProduct(name, 1)))
+	}
+	for descending, want := range map[bool][]string{
+		false: {"Apple", "Banana", "apple", "banana", "cherry", "Éclair"},
+		true:  {"Éclair", "cherry", "banana", "apple", "Banana", "Apple"},
+	} {
+		query := models.ProductQuery{Limit: 2, SortBy: models.SortByName, Descending: descending}
+		var seen []string
+		for {
+			page, err := repos.Products.FindAll(ctx, query)
+			require.NoError(t, err)
+			seen = append(seen, names(page.Items)...)
+			if !page.HasMore {
+				break
+			}
+			cursor := models.CursorAfter(page.Items[len(page.Items)-1], query.SortBy, query.Descending)
+			query.After = &cursor
+		}
+		assert.Equal(t, want, seen, "descending: %v", descending)
+	}
+}
+
+func testFiltering(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	for i, name := range []string{"Red Apple", "Green Apple", "Banana", "Apple_Pie", "Apple%Tart"} {
+		require.NoError(t, repos.Products.Create(ctx, NewProduct(name, i*10)))
+	}
+
+	page, err := repos.Products.FindAll(ctx, models.ProductQuery{Limit: 10, SortBy: models.SortByName, NameContains: "apple"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), page.Total)
+
+	// Wildcard characters in the filter match literally
+	page, err = repos.Products.FindAll(ctx, models.ProductQuery{Limit: 10, SortBy: models.SortByName, NameContains: "_"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Apple_Pie"}, names(page.Items))
+
+	page, err = repos.Products.FindAll(ctx, models.ProductQuery{Limit: 10, SortBy: models.SortByName, NameContains: "%"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Apple%Tart"}, names(page.Items))
+
+	minStock, maxStock := 10, 30
+	page, err = repos.Products.FindAll(ctx, models.ProductQuery{Limit: 10, SortBy: models.SortByStock, MinStock: &minStock, MaxStock: &maxStock})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	assert.Equal(t, []string{"Green Apple", "Banana", "Apple_Pie"}, names(page.Items))
+}
+
+func testMovements(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	productID := uuid.New()
+	start := time.Now().UTC().Truncate(time.Millisecond)
+
+	balance := 0
+	for i, delta := range []int{5, -2, 4} {
+		balance += delta
+		require.NoError(t, repos.Movements.Append(ctx, models.StockMovement{
+			ID:        uuid.New(),
+			ProductID: productID,
+			Delta:     delta,
+			Balance:   balance,
+			Reason:    "step",
+			Actor:     "repotest",
+			CreatedAt: start.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+	// Movements of another product must not leak into the listing
+	require.NoError(t, repos.Movements.Append(ctx, models.StockMovement{
+		ID: uuid.New(), ProductID: uuid.New(), Delta: 1, Balance: 1, Reason: "other", Actor: "repotest", CreatedAt: start,
+	}))
+
+	page, err := repos.Movements.List(ctx, models.MovementQuery{ProductID: productID, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	assert.True(t, page.HasMore)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, 4, page.Items[0].Delta)
+	assert.Equal(t, -2, page.Items[1].Delta)
+	assert.True(t, start.Add(2*time.Minute).Equal(page.Items[0].CreatedAt))
+
+	from, to := start.Add(30*time.Second), start.Add(90*time.Second)
+	page, err = repos.Movements.List(ctx, models.MovementQuery{ProductID: productID, Limit: 10, From: &from, To: &to})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, 3, page.Items[0].Balance)
+
+	for _, tc := range []struct {
+		at   time.Time
+		want int
+	}{
+		{start.Add(-time.Minute), 0},
+		{start, 5},
+		{start.Add(90 * time.Second), 3},
+		{start.Add(time.Hour), 7},
+	} {
+		got, err := repos.Movements.BalanceAt(ctx, productID, tc.at)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got, "balance at %v", tc.at)
+	}
+}
+
+func testSkewedMovements(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	productID := uuid.New()
+	start := time.Now().UTC().Truncate(time.Millisecond)
+
+	// The second writer's clock runs a minute behind the first's
+	for i, at := range []time.Time{start, start.Add(-time.Minute)} {
+		require.NoError(t, repos.Movements.Append(ctx, models.StockMovement{
+			ID: uuid.New(), ProductID: productID, Delta: 1, Balance: i + 1, Reason: "skew", Actor: "repotest", CreatedAt: at,
+		}))
+	}
+
+	page, err := repos.Movements.List(ctx, models.MovementQuery{ProductID: productID, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, 2, page.Items[0].Balance)
+	assert.False(t, page.Items[0].CreatedAt.Before(page.Items[1].CreatedAt))
+
+	for _, tc := range []struct {
+		at   time.Time
+		want int
+	}{
+		{start.Add(-time.Minute), 0},
+		{start, 2},
+	} {
+		got, err := repos.Movements.BalanceAt(ctx, productID, tc.at)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got, "balance at %v", tc.at)
+	}
+}
+
+func testTransactions(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	p := NewProduct("Transactional", 5)
+	require.NoError(t, repos.Products.Create(ctx, p))
+
+	restock := func(ctx context.Context) error {
+		adjusted, err := repos.Products.AdjustStock(ctx, p.ID, 3, time.Now())
+		if err != nil {
+			return err
+		}
+		return repos.Movements.Append(ctx, models.StockMovement{
+			ID:        uuid.New(),
+			ProductID: p.ID,
+			Delta:     3,
+			Balance:   adjusted.Stock,
+			Reason:    "restock",
+			Actor:     "repotest",
+			CreatedAt: time.Now(),
+		})
+	}
+
+	err := repos.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := restock(ctx); err != nil {
+			return err
+		}
+		return errs.Conflict("abort")
+	})
+	assert.ErrorIs(t, err, errs.ErrConflict)
+
+	found, err := repos.Products.FindByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, found.Stock)
+	page, err := repos.Movements.List(ctx, models.MovementQuery{ProductID: p.ID, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, page.Items)
+
+	// A nested call joins the outer transaction instead of committing on its own
+	err = repos.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		return repos.Transactor.WithinTransaction(ctx, restock)
+	})
+	require.NoError(t, err)
+
+	found, err = repos.Products.FindByID(ctx, p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 8, found.Stock)
+	balance, err := repos.Movements.BalanceAt(ctx, p.ID, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 8, balance)
+}
@@ -0,0 +1,16 @@
+// Encodings shared by the SQL adapters, so PostgreSQL and SQLite store the same values
+package sqlcodec
+
+import "encoding/json"
+
+// PathParams encodes path params as JSON, or NULL when there are none
+func PathParams(params map[string]string) (interface{}, error) {
+	if len(params) == 0 {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	return string(encoded), nil
+}
@@ -0,0 +1,130 @@
+// SQLite adapters for single-node deployments that want persistence without a database server
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/url"
+	"strconv"
+
+	"modernc.org/sqlite"
+)
+
+// schema lists the schema versions in order. Open applies the ones newer than the
+// database's user_version, so append new steps rather than editing old ones
+var schema = []string{`
+CREATE TABLE IF NOT EXISTS products (
+	id         TEXT PRIMARY KEY,
+	name       TEXT NOT NULL,
+	stock      INTEGER NOT NULL CHECK (stock >= 0),
+	version    INTEGER NOT NULL DEFAULT 1,
+	updated_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS products_name_idx ON products (name, id);
+CREATE INDEX IF NOT EXISTS products_stock_idx ON products (stock, id);
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+	seq        INTEGER PRIMARY KEY AUTOINCREMENT,
+	id         TEXT NOT NULL UNIQUE,
+	product_id TEXT NOT NULL,
+	delta      INTEGER NOT NULL,
+	balance    INTEGER NOT NULL,
+	reason     TEXT NOT NULL,
+	actor      TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL
+);
+CREATE INDEX IF NOT EXISTS stock_movements_product_idx ON stock_movements (product_id, seq);
+
+CREATE TABLE IF NOT EXISTS profiling (
+	id        TEXT PRIMARY KEY,
+	api_call  TEXT NOT NULL,
+	duration  INTEGER NOT NULL,
+	timestamp TIMESTAMP NOT NULL
+);
+`, `
+ALTER TABLE profiling ADD COLUMN method TEXT NOT NULL DEFAULT '';
+ALTER TABLE profiling ADD COLUMN route TEXT NOT NULL DEFAULT '';
+ALTER TABLE profiling ADD COLUMN path_params TEXT;
+ALTER TABLE profiling ADD COLUMN status INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE profiling ADD COLUMN response_size INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX profiling_route_timestamp_idx ON profiling (method, route, timestamp);
+-- duration is now in microseconds, the rows so far hold milliseconds
+UPDATE profiling SET duration = duration * 1000;
+`, `
+ALTER TABLE profiling ADD COLUMN request_id TEXT NOT NULL DEFAULT '';
+CREATE INDEX profiling_request_id_idx ON profiling (request_id) WHERE request_id <> '';
+`, `
+ALTER TABLE profiling ADD COLUMN weight REAL NOT NULL DEFAULT 1;
+`}
+
+// Open opens the database file at path and creates or upgrades the schema if needed.
+// Timestamps are stored in a sortable text format, so adapters always write UTC
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	return OpenDB(ctx, NewConnector(path))
+}
+
+type fileConnector struct {
+	dsn string
+}
+
+// NewConnector connects to the database file at path, for callers that wrap the
+// connections before passing them to OpenDB
+func NewConnector(path string) driver.Connector {
+	params := url.Values{}
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Set("_time_format", "sqlite")
+
+	// SQLite decodes the URI, so escape the path for any %, ? or # in it. Opaque
+	// keeps a relative path relative
+	dsn := url.URL{Scheme: "file", Opaque: (&url.URL{Path: path}).EscapedPath(), RawQuery: params.Encode()}
+	return fileConnector{dsn: dsn.String()}
+}
+
+func (c fileConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.Driver().Open(c.dsn)
+}
+
+func (fileConnector) Driver() driver.Driver {
+	return &sqlite.Driver{}
+}
+
+// OpenDB opens the database connector connects to and creates or upgrades the schema
+// if needed, like Open
+func OpenDB(ctx context.Context, connector driver.Connector) (*sql.DB, error) {
+	db := sql.OpenDB(connector)
+
+	// SQLite allows a single writer, and an in-memory database exists per connection
+	db.SetMaxOpenConns(1)
+
+	if err := upgradeSchema(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func upgradeSchema(ctx context.Context, db *sql.DB) error {
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return mapError(err, "read schema version")
+	}
+
+	for ; version < len(schema); version++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return mapError(err, "begin schema upgrade")
+		}
+		// PRAGMA does not take parameters, version is an integer we control
+		_, err = tx.ExecContext(ctx, schema[version]+"; PRAGMA user_version = "+strconv.Itoa(version+1))
+		if err != nil {
+			tx.Rollback()
+			return mapError(err, "upgrade schema to version "+strconv.Itoa(version+1))
+		}
+		if err := tx.Commit(); err != nil {
+			return mapError(err, "commit schema upgrade")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
+	"context"
+	"database/sql"
+	"errors"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// mapError translates database/sql and SQLite errors into domain errors
+func mapError(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	// Checked first, drivers also wrap context errors in their own error types
+	switch {
+	case errors.Is(err, context.Canceled):
+		return errs.Wrap(errs.KindCanceled, err, message)
+	case errors.Is(err, context.DeadlineExceeded):
+		return errs.Wrap(errs.KindTimeout, err, message)
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return errs.Wrap(errs.KindNotFound, err, message)
+	}
+
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch code := sqliteErr.Code(); {
+		case code == sqlite3.SQLITE_CONSTRAINT_CHECK, code == sqlite3.SQLITE_CONSTRAINT_NOTNULL:
+			return errs.Wrap(errs.KindValidation, err, message)
+		case code&0xff == sqlite3.SQLITE_CONSTRAINT:
+			// unique and primary key violations
+			return errs.Wrap(errs.KindConflict, err, message)
+		case code&0xff == sqlite3.SQLITE_BUSY, code&0xff == sqlite3.SQLITE_LOCKED, code&0xff == sqlite3.SQLITE_CANTOPEN:
+			return errs.Wrap(errs.KindUnavailable, err, message)
+		}
+		return errs.Wrap(errs.KindInternal, err, message)
+	}
+
+	if errors.Is(err, sql.ErrConnDone) {
+		return errs.Wrap(errs.KindUnavailable, err, message)
+	}
+
+	return errs.Wrap(errs.KindInternal, err, message)
+}
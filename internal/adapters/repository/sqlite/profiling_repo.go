@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/adapters/repository/sqlcodec"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+)
+
+type ProfilingRepository struct {
+	db *sql.DB
+}
+
+func NewProfilingRepository(db *sql.DB) ports.IProfilingRepository {
+	return &ProfilingRepository{db: db}
+}
+
+// profilingColumns is the number of values inserted per record
+const profilingColumns = 11
+
+func (r *ProfilingRepository) CreateMany(ctx context.Context, records []models.Profiling) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	var statement strings.Builder
+	statement.WriteString("INSERT INTO profiling (id, api_call, method, route, path_params, status, response_size, request_id, duration, timestamp, weight) VALUES ")
+	args := make([]interface{}, 0, len(records)*profilingColumns)
+
+	for i, record := range records {
+		pathParams, err := sqlcodec.PathParams(record.PathParams)
+		if err != nil {
+			return mapError(err, "encode path params")
+		}
+
+		if i > 0 {
+			statement.WriteString(", ")
+		}
+		statement.WriteString("(")
+		for column := 1; column <= profilingColumns; column++ {
+			if column > 1 {
+				statement.WriteString(", ")
+			}
+			statement.WriteString("?" + strconv.Itoa(len(args)+column))
+		}
+		statement.WriteString(")")
+
+		args = append(args, record.ID, record.APICall, record.Method, record.Route, pathParams,
+			record.Status, record.ResponseSize, record.RequestID, record.Duration, record.Timestamp.UTC(), record.SampleWeight())
+	}
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, statement.String(), args...)
+	return mapError(err, "insert profiling records")
+}
+
+// Summarize streams the matching records into a summary in Go, SQLite has no percentile functions
+func (r *ProfilingRepository) Summarize(ctx context.Context, query models.ProfilingQuery) ([]models.RouteStats, error) {
+	statement := "SELECT method, route, status, duration, timestamp, weight FROM profiling WHERE timestamp >= ?1 AND timestamp < ?2"
+	args := []interface{}{query.From.UTC(), query.To.UTC()}
+	if query.Method != "" {
+		args = append(args, query.Method)
+		statement += " AND method = ?" + strconv.Itoa(len(args))
+	}
+	if query.Route != "" {
+		args = append(args, query.Route)
+		statement += " AND route = ?" + strconv.Itoa(len(args))
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, mapError(err, "query profiling records")
+	}
+	defer rows.Close()
+
+	summarizer := models.NewProfilingSummarizer(query)
+	for rows.Next() {
+		var p models.Profiling
+		if err := rows.Scan(&p.Method, &p.Route, &p.Status, &p.Duration, &p.Timestamp, &p.Weight); err != nil {
+			return nil, mapError(err, "scan profiling record")
+		}
+		summarizer.Add(p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, mapError(err, "iterate profiling records")
+	}
+
+	return summarizer.Stats(), nil
+}
@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/adapters/repository/repotest"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDB(t *testing.T) *sql.DB {
+	db, err := Open(context.Background(), filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestRepositoryConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		db := testDB(t)
+		return repotest.Repositories{
+			Products:   NewProductRepository(db),
+			Movements:  NewStockMovementRepository(db),
+			Transactor: NewTransactor(db),
+		}
+	})
+}
+
+func TestProfilingConformance(t *testing.T) {
+	repotest.RunProfiling(t, func(t *testing.T) ports.IProfilingRepository {
+		return NewProfilingRepository(testDB(t))
+	})
+}
+
+func TestProfilingStoresRequestID(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	record := models.Profiling{ID: uuid.New(), APICall: "GET /products", RequestID: "req-1", Timestamp: time.Now()}
+	require.NoError(t, NewProfilingRepository(db).CreateMany(ctx, []models.Profiling{record}))
+
+	var requestID string
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT request_id FROM profiling WHERE id = ?", record.ID).Scan(&requestID))
+	assert.Equal(t, "req-1", requestID)
+}
+
+func TestOpenEscapesPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "100% sure?#.db")
+	db, err := Open(context.Background(), path)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+	assert.FileExists(t, path)
+}
+
+func TestOpenConvertsMillisecondDurations(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "old.db")
+	old, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	_, err = old.ExecContext(ctx, schema[0]+"; PRAGMA user_version = 1")
+	require.NoError(t, err)
+	_, err = old.ExecContext(ctx, "INSERT INTO profiling (id, api_call, duration, timestamp) VALUES (?, 'GET /products', 12, ?)",
+		uuid.NewString(), time.Now().UTC())
+	require.NoError(t, err)
+	require.NoError(t, old.Close())
+
+	db, err := Open(ctx, path)
+	require.NoError(t, err)
+	defer db.Close()
+	var duration int64
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT duration FROM profiling").Scan(&duration))
+	assert.Equal(t, int64(12000), duration)
+}
+
+func TestHealthChecker(t *testing.T) {
+	db := testDB(t)
+	checker := NewHealthChecker(db)
+	assert.NoError(t, checker.Check(context.Background()))
+
+	require.NoError(t, db.Close())
+	assert.Error(t, checker.Check(context.Background()))
+}
@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/utils"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	fiberUtils "github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// headerCarrier reads trace context from the request and writes it to the response
+type headerCarrier struct {
+	ctx *fiber.Ctx
+}
+
+func (c headerCarrier) Get(key string) string {
+	return c.ctx.Get(key)
+}
+
+func (c headerCarrier) Set(key, value string) {
+	c.ctx.Set(key, value)
+}
+
+func (c headerCarrier) Keys() []string {
+	var keys []string
+	c.ctx.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
+
+// Middleware starts the server span of each request, continuing the trace of an
+// incoming traceparent header. The span context goes into the user context so the
+// service and repository spans become its children, and back out in the response
+// headers so callers can find the trace
+func (t *Tracing) Middleware(ctx *fiber.Ctx) error {
+	method := strings.Clone(ctx.Method())
+	parent := t.propagator.Extract(ctx.UserContext(), headerCarrier{ctx})
+	spanCtx, span := t.tracer.Start(parent, method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(method),
+			semconv.URLPath(strings.Clone(ctx.Path())),
+		),
+	)
+	defer span.End()
+
+	ctx.SetUserContext(spanCtx)
+	t.propagator.Inject(spanCtx, headerCarrier{ctx})
+	self := ctx.Route()
+
+	// Run the error handler here so the recorded status is the one sent. The inner
+	// middleware already handle errors, so the span status comes from the response
+	utils.HandleError(ctx, ctx.Next())
+
+	route := utils.RouteTemplate(ctx, self)
+	if route != utils.UnmatchedRoute {
+		span.SetAttributes(semconv.HTTPRoute(route))
+	}
+	span.SetName(method + " " + route)
+
+	status := ctx.Response().StatusCode()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if status >= fiber.StatusInternalServerError {
+		span.SetStatus(codes.Error, fiberUtils.StatusMessage(status))
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package tracing
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// adapterKey holds the name of the adapter behind a port, see the config Backend constants
+const adapterKey = attribute.Key("hexa.adapter")
+
+// spans starts the spans of one repository, named like "products.FindByID"
+type spans struct {
+	tracer     trace.Tracer
+	adapter    string
+	repository string
+}
+
+func (s spans) start(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, s.repository+"."+operation, trace.WithAttributes(adapterKey.String(s.adapter)))
+}
+
+// end records err, labelled with its kind, and ends the span
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetAttributes(semconv.ErrorTypeKey.String(errs.KindOf(err).String()))
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+type productRepository struct {
+	next  ports.IProductRepository
+	spans spans
+}
+
+// ProductRepository starts a span for every call made to next, labelled with the adapter name
+func (t *Tracing) ProductRepository(next ports.IProductRepository, adapter string) ports.IProductRepository {
+	return &productRepository{next: next, spans: spans{tracer: t.tracer, adapter: adapter, repository: "products"}}
+}
+
+func (r *productRepository) FindAll(ctx context.Context, query models.ProductQuery) (models.ProductPage, error) {
+	ctx, span := r.spans.start(ctx, "FindAll")
+	page, err := r.next.FindAll(ctx, query)
+	end(span, err)
+	return page, err
+}
+
+func (r *productRepository) FindByID(ctx context.Context, id uuid.UUID) (models.Product, error) {
+	ctx, span := r.spans.start(ctx, "FindByID")
+	product, err := r.next.FindByID(ctx, id)
+	end(span, err)
+	return product, err
+}
+
+func (r *productRepository) Create(ctx context.Context, product models.Product) error {
+	ctx, span := r.spans.start(ctx, "Create")
+	err := r.next.Create(ctx, product)
+	end(span, err)
+	return err
+}
+
+func (r *productRepository) Update(ctx context.Context, product models.Product) (models.Product, error) {
+	ctx, span := r.spans.start(ctx, "Update")
+	updated, err := r.next.Update(ctx, product)
+	end(span, err)
+	return updated, err
+}
+
+func (r *productRepository) AdjustStock(ctx context.Context, id uuid.UUID, delta int, updatedAt time.Time) (models.Product, error) {
+	ctx, span := r.spans.start(ctx, "AdjustStock")
+	product, err := r.next.AdjustStock(ctx, id, delta, updatedAt)
+	end(span, err)
+	return product, err
+}
+
+func (r *productRepository) Delete(ctx context.Context, id uuid.UUID, expectedVersion int64) error {
+	ctx, span := r.spans.start(ctx, "Delete")
+	err := r.next.Delete(ctx, id, expectedVersion)
+	end(span, err)
+	return err
+}
+
+type stockMovementRepository struct {
+	next  ports.IStockMovementRepository
+	spans spans
+}
+
+// StockMovementRepository starts a span for every call made to next, labelled with the adapter name
+func (t *Tracing) StockMovementRepository(next ports.IStockMovementRepository, adapter string) ports.IStockMovementRepository {
+	return &stockMovementRepository{next: next, spans: spans{tracer: t.tracer, adapter: adapter, repository: "stock_movements"}}
+}
+
+func (r *stockMovementRepository) Append(ctx context.Context, movement models.StockMovement) error {
+	ctx, span := r.spans.start(ctx, "Append")
+	err := r.next.Append(ctx, movement)
+	end(span, err)
+	return err
+}
+
+func (r *stockMovementRepository) List(ctx context.Context, query models.MovementQuery) (models.MovementPage, error) {
+	ctx, span := r.spans.start(ctx, "List")
+	page, err := r.next.List(ctx, query)
+	end(span, err)
+	return page, err
+}
+
+func (r *stockMovementRepository) BalanceAt(ctx context.Context, productID uuid.UUID, at time.Time) (int, error) {
+	ctx, span := r.spans.start(ctx, "BalanceAt")
+	balance, err := r.next.BalanceAt(ctx, productID, at)
+	end(span, err)
+	return balance, err
+}
+
+type transactor struct {
+	next  ports.ITransactor
+	spans spans
+}
+
+// Transactor starts a span around each transaction, parent of the calls made inside it
+func (t *Tracing) Transactor(next ports.ITransactor, adapter string) ports.ITransactor {
+	return &transactor{next: next, spans: spans{tracer: t.tracer, adapter: adapter, repository: "transactor"}}
+}
+
+func (t *transactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	ctx, span := t.spans.start(ctx, "WithinTransaction")
+	err := t.next.WithinTransaction(ctx, fn)
+	end(span, err)
+	return err
+}
+
+type profilingRepository struct {
+	next  ports.IProfilingRepository
+	spans spans
+}
+
+// ProfilingRepository starts a span for every call made to next, labelled with the adapter name
+func (t *Tracing) ProfilingRepository(next ports.IProfilingRepository, adapter string) ports.IProfilingRepository {
+	return &profilingRepository{next: next, spans: spans{tracer: t.tracer, adapter: adapter, repository: "profiling"}}
+}
+
+func (r *profilingRepository) CreateMany(ctx context.Context, records []models.Profiling) error {
+	ctx, span := r.spans.start(ctx, "CreateMany")
+	err := r.next.CreateMany(ctx, records)
+	end(span, err)
+	return err
+}
+
+func (r *profilingRepository) Summarize(ctx context.Context, query models.ProfilingQuery) ([]models.RouteStats, error) {
+	ctx, span := r.spans.start(ctx, "Summarize")
+	stats, err := r.next.Summarize(ctx, query)
+	end(span, err)
+	return stats, err
+}
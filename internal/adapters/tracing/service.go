@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"CRUD-Go-Hexa-MongoDB/internal/utils"
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// responseCodeKey holds the status code a service call answered with
+const responseCodeKey = attribute.Key("hexa.response.code")
+
+type productService struct {
+	next   ports.IProductService
+	tracer trace.Tracer
+}
+
+// ProductService starts a span for every call made to next
+func (t *Tracing) ProductService(next ports.IProductService) ports.IProductService {
+	return &productService{next: next, tracer: t.tracer}
+}
+
+// call runs fn in a span named after the service method. Only server errors mark the
+// span as failed, validation and not found responses are the service working as intended
+func (s *productService) call(ctx context.Context, method string, fn func(ctx context.Context) utils.ServiceResponse) utils.ServiceResponse {
+	ctx, span := s.tracer.Start(ctx, "ProductService."+method)
+	defer span.End()
+
+	response := fn(ctx)
+	span.SetAttributes(responseCodeKey.Int(response.Code))
+	if response.Code >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, response.Message)
+	}
+	return response
+}
+
+func (s *productService) FindAll(ctx context.Context, queryParams map[string]string) utils.ServiceResponse {
+	return s.call(ctx, "FindAll", func(ctx context.Context) utils.ServiceResponse {
+		return s.next.FindAll(ctx, queryParams)
+	})
+}
+
+func (s *productService) FindByID(ctx context.Context, idStr string) utils.ServiceResponse {
+	return s.call(ctx, "FindByID", func(ctx context.Context) utils.ServiceResponse {
+		return s.next.FindByID(ctx, idStr)
+	})
+}
+
+func (s *productService) Create(ctx context.Context, req models.CreateProductRequest) utils.ServiceResponse {
+	return s.call(ctx, "Create", func(ctx context.Context) utils.ServiceResponse {
+		return s.next.Create(ctx, req)
+	})
+}
+
+func (s *productService) Update(ctx context.Context, idStr string, req models.UpdateProductRequest, expected models.ExpectedVersions) utils.ServiceResponse {
+	return s.call(ctx, "Update", func(ctx context.Context) utils.ServiceResponse {
+		return s.next.Update(ctx, idStr, req, expected)
+	})
+}
+
+func (s *productService) Patch(ctx context.Context, idStr string, req models.PatchProductRequest, expected models.ExpectedVersions) utils.ServiceResponse {
+	return s.call(ctx, "Patch", func(ctx context.Context) utils.ServiceResponse {
+		return s.next.Patch(ctx, idStr, req, expected)
+	})
+}
+
+func (s *productService) Delete(ctx context.Context, idStr string, expected models.ExpectedVersions) utils.ServiceResponse {
+	return s.call(ctx, "Delete", func(ctx context.Context) utils.ServiceResponse {
+		return s.next.Delete(ctx, idStr, expected)
+	})
+}
+
+func (s *productService) AdjustStock(ctx context.Context, idStr string, req models.AdjustStockRequest) utils.ServiceResponse {
+	return s.call(ctx, "AdjustStock", func(ctx context.Context) utils.ServiceResponse {
+		return s.next.AdjustStock(ctx, idStr, req)
+	})
+}
+
+func (s *productService) ListMovements(ctx context.Context, idStr string, queryParams map[string]string) utils.ServiceResponse {
+	return s.call(ctx, "ListMovements", func(ctx context.Context) utils.ServiceResponse {
+		return s.next.ListMovements(ctx, idStr, queryParams)
+	})
+}
@@ -0,0 +1,126 @@
+// OpenTelemetry tracing. As with metrics, ports are wrapped in decorators that start a
+// span per call; the SQL adapters add a span per statement and the MongoDB client one
+// per command
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// instrumentationName names the tracer every span of this module comes from
+const instrumentationName = "CRUD-Go-Hexa-MongoDB"
+
+// Span exporters selectable with Options.Exporter
+const (
+	// ExporterNone records nothing
+	ExporterNone = "none"
+	// ExporterOTLP sends spans over OTLP/HTTP, configured by the standard OTEL_EXPORTER_OTLP_* variables
+	ExporterOTLP = "otlp"
+	// ExporterFile appends spans to a file as JSON, for inspection without a collector
+	ExporterFile = "file"
+)
+
+type Options struct {
+	// Exporter is one of the Exporter constants, empty means ExporterNone
+	Exporter string
+	// File is where ExporterFile writes
+	File string
+	// SampleRate is the share of new traces recorded; incoming sampled traces are always followed
+	SampleRate float64
+}
+
+// Tracing starts the spans of one application
+type Tracing struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	shutdown   func(ctx context.Context) error
+}
+
+// New traces with provider and W3C trace context propagation
+func New(provider trace.TracerProvider) *Tracing {
+	return &Tracing{
+		tracer:     provider.Tracer(instrumentationName),
+		propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+		shutdown:   func(context.Context) error { return nil },
+	}
+}
+
+// Open builds the tracer provider described by options and installs it globally,
+// so the database adapters and drivers trace with it too
+func Open(ctx context.Context, options Options) (*Tracing, error) {
+	if options.SampleRate < 0 || options.SampleRate > 1 {
+		return nil, fmt.Errorf("tracing sample rate %v must be between 0 and 1", options.SampleRate)
+	}
+
+	var exporter sdktrace.SpanExporter
+	var closeFile func() error
+	switch options.Exporter {
+	case ExporterNone, "":
+		return New(noop.NewTracerProvider()), nil
+	case ExporterOTLP:
+		otlp, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("create OTLP exporter: %w", err)
+		}
+		exporter = otlp
+	case ExporterFile:
+		file, err := os.OpenFile(options.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open trace file: %w", err)
+		}
+		stdout, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("create file exporter: %w", err)
+		}
+		exporter, closeFile = stdout, file.Close
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, want %s, %s or %s", options.Exporter, ExporterNone, ExporterOTLP, ExporterFile)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName("hexa")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("describe tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(options.SampleRate))),
+	)
+
+	t := New(provider)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(t.propagator)
+	t.shutdown = func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeFile != nil {
+			err = errors.Join(err, closeFile())
+		}
+		return err
+	}
+	return t, nil
+}
+
+// Shutdown exports the spans still buffered and releases the exporter
+func (t *Tracing) Shutdown(ctx context.Context) error {
+	return t.shutdown(ctx)
+}
@@ -0,0 +1,197 @@
+package tracing
+
+import (
+	memoryRepo "CRUD-Go-Hexa-MongoDB/internal/adapters/repository/memory"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/services"
+	"CRUD-Go-Hexa-MongoDB/internal/utils"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"modernc.org/sqlite"
+)
+
+func newRecorder() (*Tracing, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	return New(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))), recorder
+}
+
+// attributes flattens the attributes of a span for assertions
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	values := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func TestMiddleware(t *testing.T) {
+	tracer, recorder := newRecorder()
+	app := fiber.New()
+	app.Use(tracer.Middleware)
+	app.Get("/products/:id", func(ctx *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusServiceUnavailable, "down")
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest("GET", "/products/42", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /products/:id", span.Name())
+	assert.Equal(t, traceID, span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Equal(t, "/products/:id", attributes(span)[semconv.HTTPRouteKey].AsString())
+	assert.Equal(t, int64(503), attributes(span)[semconv.HTTPResponseStatusCodeKey].AsInt64())
+
+	// The response names the server span so callers can look it up
+	assert.Contains(t, resp.Header.Get("traceparent"), traceID+"-"+span.SpanContext().SpanID().String())
+}
+
+// In the application the inner middleware turn errors into responses before the
+// tracing middleware sees them, so the span status has to come from the response
+func TestMiddlewareBehindErrorHandlingMiddleware(t *testing.T) {
+	tracer, recorder := newRecorder()
+	app := fiber.New()
+	app.Use(tracer.Middleware)
+	app.Use(func(ctx *fiber.Ctx) error {
+		utils.HandleError(ctx, ctx.Next())
+		return nil
+	})
+	app.Get("/products/:id", func(ctx *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusInternalServerError, "boom")
+	})
+	app.Get("/missing/:id", func(ctx *fiber.Ctx) error {
+		return fiber.ErrNotFound
+	})
+
+	for _, path := range []string{"/products/42", "/missing/42", "/unknown"} {
+		_, err := app.Test(httptest.NewRequest("GET", path, nil))
+		require.NoError(t, err)
+	}
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+	assert.Equal(t, "GET "+utils.UnmatchedRoute, spans[2].Name())
+	assert.Equal(t, int64(404), attributes(spans[2])[semconv.HTTPResponseStatusCodeKey].AsInt64())
+}
+
+func TestDecorators(t *testing.T) {
+	tracer, recorder := newRecorder()
+	store := memoryRepo.NewStore()
+	service := tracer.ProductService(services.NewProductService(
+		tracer.ProductRepository(memoryRepo.NewProductRepository(store), "memory"),
+		tracer.StockMovementRepository(memoryRepo.NewStockMovementRepository(store), "memory"),
+		tracer.Transactor(memoryRepo.NewTransactor(store), "memory"),
+	))
+
+	response := service.FindByID(context.Background(), "00000000-0000-0000-0000-000000000001")
+	assert.Equal(t, fiber.StatusNotFound, response.Code)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	repository, call := spans[0], spans[1]
+
+	assert.Equal(t, "ProductService.FindByID", call.Name())
+	assert.Equal(t, int64(404), attributes(call)[responseCodeKey].AsInt64())
+	// Expected failures leave the service span successful
+	assert.Equal(t, codes.Unset, call.Status().Code)
+
+	assert.Equal(t, "products.FindByID", repository.Name())
+	assert.Equal(t, call.SpanContext().SpanID(), repository.Parent().SpanID())
+	assert.Equal(t, "memory", attributes(repository)[adapterKey].AsString())
+	assert.Equal(t, "not found", attributes(repository)[semconv.ErrorTypeKey].AsString())
+	assert.Equal(t, codes.Error, repository.Status().Code)
+}
+
+// memoryConnector connects to a fresh in-memory SQLite database
+type memoryConnector struct{}
+
+func (c memoryConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.Driver().Open(":memory:")
+}
+
+func (memoryConnector) Driver() driver.Driver {
+	return &sqlite.Driver{}
+}
+
+func TestSQL(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	db := sql.OpenDB(SQLConnector(memoryConnector{}, semconv.DBSystemSqlite))
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	_, err := db.ExecContext(ctx, "CREATE TABLE t (v INTEGER)")
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "INSERT INTO missing VALUES (?)", 1)
+	require.Error(t, err)
+
+	// Statements in a transaction are traced too
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	var count int
+	require.NoError(t, tx.QueryRowContext(ctx, "SELECT count(*) FROM t").Scan(&count))
+	require.NoError(t, tx.Commit())
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "CREATE", spans[0].Name())
+	assert.Equal(t, "CREATE TABLE t (v INTEGER)", attributes(spans[0])[semconv.DBQueryTextKey].AsString())
+	assert.Equal(t, "sqlite", attributes(spans[0])[semconv.DBSystemKey].AsString())
+	assert.Equal(t, "INSERT", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "SELECT", spans[2].Name())
+}
+
+func TestSQLTruncatesLongStatements(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	db := sql.OpenDB(SQLConnector(memoryConnector{}, semconv.DBSystemSqlite))
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	_, err := db.ExecContext(ctx, "CREATE TABLE t (v TEXT)")
+	require.NoError(t, err)
+	// Multi-byte values so the cut has to avoid splitting one
+	statement := "INSERT INTO t (v) VALUES ('é')" + strings.Repeat(", ('é')", 500)
+	_, err = db.ExecContext(ctx, statement)
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	text := attributes(spans[1])[semconv.DBQueryTextKey].AsString()
+	assert.LessOrEqual(t, len(text), maxQueryText+len("…"))
+	assert.True(t, strings.HasPrefix(statement, strings.TrimSuffix(text, "…")))
+	assert.True(t, strings.HasSuffix(text, "…"))
+	assert.True(t, utf8.ValidString(text))
+}
@@ -4,71 +4,214 @@ package app
 
 import (
 	handlers "CRUD-Go-Hexa-MongoDB/internal/adapters/handlers"
+	"CRUD-Go-Hexa-MongoDB/internal/adapters/metrics"
+	"CRUD-Go-Hexa-MongoDB/internal/adapters/tracing"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/services"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"CRUD-Go-Hexa-MongoDB/internal/utils"
+	"CRUD-Go-Hexa-MongoDB/pkg/config"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-
-	mongoRepo "CRUD-Go-Hexa-MongoDB/internal/adapters/repository/mongo"
-	postgreSQLRepo "CRUD-Go-Hexa-MongoDB/internal/adapters/repository/postgresql"
-	"CRUD-Go-Hexa-MongoDB/internal/domain/services"
-	"CRUD-Go-Hexa-MongoDB/pkg/config"
-
-	// "context"
 	"log"
-	// "time"
+	"log/slog"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
-	_ "github.com/lib/pq"
-	mongoDriver "go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"github.com/gofiber/fiber/v2/middleware/timeout"
 )
 
-func Setup() *fiber.App {
-	cfg := config.LoadConfig()
+// defaultRequestTimeout applies when the configuration leaves RequestTimeout unset
+const defaultRequestTimeout = 10 * time.Second
+
+// cleanupTimeout bounds each step of Close after the requests are drained, so a
+// slow step neither starves the next ones nor finds its deadline already spent
+const cleanupTimeout = 5 * time.Second
+
+// Application is the HTTP server together with the resources it owns
+type Application struct {
+	*fiber.App
+	profilingService ports.IProfilingService
+	conns            *connections
+	tracing          *tracing.Tracing
+	// recovering is set while the application started without its profiling store
+	recovering *recoveringProfilingStore
+}
 
-	//Connect to MongoDB
-	clientOptions := options.Client().ApplyURI(cfg.MongoURI)
-	client, err := mongoDriver.Connect(context.Background(), clientOptions)
-	if err != nil {
-		log.Fatal(err)
+// Close stops accepting requests and waits for in-flight ones until ctx is done,
+// then flushes pending profiling records, closes the databases and exports the last
+// spans, each within its own cleanupTimeout
+func (a *Application) Close(ctx context.Context) error {
+	shutdownErr := a.App.ShutdownWithContext(ctx)
+	if shutdownErr != nil {
+		shutdownErr = fmt.Errorf("drain requests: %w", shutdownErr)
 	}
 
-	//Ping to MongoDB
-	err = client.Ping(context.Background(), nil)
-	if err != nil {
-		log.Fatal(err)
+	// The cleanup runs even when draining used up ctx
+	cleanup := func(step func(context.Context) error) error {
+		stepCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+		defer cancel()
+		return step(stepCtx)
+	}
+
+	flushErr := cleanup(a.profilingService.Flush)
+	if flushErr != nil {
+		flushErr = fmt.Errorf("flush profiling: %w", flushErr)
 	}
 
-	//Obtain reference to MongoDB database
-	mongoDB := client.Database(cfg.DBName)
+	if a.recovering != nil {
+		a.recovering.Close()
+	}
+	closeErr := cleanup(a.conns.Close)
+	if closeErr != nil {
+		closeErr = fmt.Errorf("close databases: %w", closeErr)
+	}
+	tracingErr := cleanup(a.tracing.Shutdown)
+	if tracingErr != nil {
+		tracingErr = fmt.Errorf("shut down tracing: %w", tracingErr)
+	}
+	return errors.Join(shutdownErr, flushErr, closeErr, tracingErr)
+}
 
-	//Connect to PostgreSQL
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.PostgresHost, cfg.PostgresPort, cfg.PostgresUser, cfg.PostgresPass, cfg.PostgresDBName)
+// Setup wires the application for the configuration found in the config file
+// and the environment
+func Setup(logger *slog.Logger) *Application {
+	return New(context.Background(), config.LoadConfig(), logger)
+}
 
-	db, err := sql.Open("postgres", dsn)
+// New wires the application for the given configuration. Log calls made with a
+// request context carry its request ID. Cancelling ctx stops waiting for databases
+// that are not up yet, during startup and, for an optional profiling store, after it
+func New(ctx context.Context, cfg *config.Config, logger *slog.Logger) *Application {
+	logger = slog.New(utils.ContextHandler(logger.Handler()))
+	logger.Debug("configuration", "config", cfg)
+
+	// Tracing comes first so the database drivers trace from the first connection
+	tracer, err := tracing.Open(context.Background(), tracing.Options{
+		Exporter:   cfg.TracingExporter,
+		File:       cfg.TracingFile,
+		SampleRate: cfg.TracingSampleRate,
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	err = db.Ping()
+	m := metrics.New()
+	conns := newConnections(ctx, cfg, logger)
+	conns.onOpenSQL = func(name string, db *sql.DB) {
+		if err := m.RegisterDB(name, db); err != nil {
+			logger.Error("failed to export connection pool statistics", "database", name, "error", err)
+		}
+	}
+	// Databases may still be starting alongside us, so give them a few tries
+	connect := backoff{Attempts: cfg.ConnectAttempts, Initial: cfg.ConnectBackoff, Max: cfg.ConnectBackoffMax}
+
+	products, err := retry(ctx, connect, logger, "product store", func() (productStore, error) {
+		return newProductStore(cfg.ProductRepository, conns)
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	profilingRepo := mongoRepo.NewProfilingRepository(mongoDB)
-	profilingService := services.NewProfilingService(profilingRepo)
+	openProfiling := func() (ports.IProfilingRepository, error) {
+		return newProfilingStore(cfg.ProfilingRepository, conns)
+	}
+	profilingRepo, err := retry(ctx, connect, logger, "profiling store", openProfiling)
+	var recovering *recoveringProfilingStore
+	if err != nil {
+		if !cfg.ProfilingOptional || errors.As(err, new(permanentError)) || ctx.Err() != nil {
+			log.Fatal(err)
+		}
+		logger.Warn("profiling store unavailable, starting with profiling disabled", "error", err)
+		recovering = newRecoveringProfilingStore(ctx, openProfiling, func() ports.IHealthChecker {
+			return conns.healthChecker(cfg.ProfilingRepository)
+		}, err, connect, logger)
+		profilingRepo = recovering
+	}
 
-	productRepo := postgreSQLRepo.NewProductRepository(db)
-	productService := services.NewProductService(productRepo)
-	productController := handlers.NewProductController(productService, profilingService)
+	// Every port is instrumented the same way whichever adapter backs it
+	adapter := cfg.ProductRepository
+	products.products = tracer.ProductRepository(m.ProductRepository(products.products, adapter), adapter)
+	products.movements = tracer.StockMovementRepository(m.StockMovementRepository(products.movements, adapter), adapter)
+	products.transactor = tracer.Transactor(m.Transactor(products.transactor, adapter), adapter)
+	adapter = cfg.ProfilingRepository
+	profilingRepo = tracer.ProfilingRepository(m.ProfilingRepository(profilingRepo, adapter), adapter)
+
+	overflow := services.OverflowPolicy(cfg.ProfilingOverflow)
+	if overflow != "" && overflow != services.OverflowDrop && overflow != services.OverflowBlock {
+		log.Fatalf("unknown PROFILING_OVERFLOW policy %q, want %s or %s", overflow, services.OverflowDrop, services.OverflowBlock)
+	}
 
-	app := fiber.New()
-	app.Get("/products", productController.FindAll)
-	app.Get("/products/:id", productController.FindByID)
-	app.Post("/products", productController.Create)
-	app.Put("/products/:id", productController.Update)
-	app.Delete("/products/:id", productController.Delete)
+	sampling := &services.SamplingRules{
+		Rate:          cfg.ProfilingSampleRate,
+		SlowThreshold: cfg.ProfilingSlowThreshold,
+		KeepErrors:    cfg.ProfilingKeepErrors,
+		Include:       cfg.ProfilingInclude,
+		Exclude:       cfg.ProfilingExclude,
+	}
+	if err := sampling.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	profilingService := services.NewProfilingService(profilingRepo, services.ProfilingOptions{
+		QueueSize:     cfg.ProfilingQueueSize,
+		BatchSize:     cfg.ProfilingBatchSize,
+		FlushInterval: cfg.ProfilingFlushInterval,
+		Overflow:      overflow,
+		Sampling:      sampling,
+		Logger:        logger,
+	})
+	if err := m.RegisterProfiling(profilingService); err != nil {
+		log.Fatal(err)
+	}
+	productService := tracer.ProductService(services.NewProductService(products.products, products.movements, products.transactor))
+	productController := handlers.NewProductController(productService)
+	profilingController := handlers.NewProfilingController(profilingService)
+	healthChecks := conns.healthChecks()
+	if recovering != nil {
+		healthChecks = append(healthChecks, services.HealthCheck{Name: "profiling", Checker: recovering})
+	}
+	healthService := services.NewHealthService(healthChecks, services.HealthOptions{
+		Timeout:       cfg.HealthTimeout,
+		SlowThreshold: cfg.HealthSlowThreshold,
+	})
+	healthController := handlers.NewHealthController(healthService)
+
+	requestTimeout := cfg.RequestTimeout
+	if requestTimeout <= 0 {
+		requestTimeout = defaultRequestTimeout
+	}
+
+	app := fiber.New(fiber.Config{
+		ReadTimeout:  cfg.ServerReadTimeout,
+		WriteTimeout: cfg.ServerWriteTimeout,
+		IdleTimeout:  cfg.ServerIdleTimeout,
+	})
+	app.Use(handlers.RequestID)
+	// Probes come before the remaining middleware, which they never reach, so that
+	// frequent polling stays out of traces, metrics, logs and profiling
+	app.Get("/healthz", healthController.Liveness)
+	app.Get("/readyz", healthController.Readiness)
+	app.Use(tracer.Middleware)
+	app.Use(m.Middleware)
+	app.Use(handlers.AccessLog(logger))
+	app.Use(handlers.Profiling(profilingService))
+	app.Use(handlers.Actor)
+	// Client disconnects and the per-route timeouts both cancel the request context
+	app.Use(handlers.CancelOnDisconnect)
+	app.Get("/products", timeout.NewWithContext(productController.FindAll, requestTimeout))
+	app.Get("/products/:id", timeout.NewWithContext(productController.FindByID, requestTimeout))
+	app.Post("/products", timeout.NewWithContext(productController.Create, requestTimeout))
+	app.Put("/products/:id", timeout.NewWithContext(productController.Update, requestTimeout))
+	app.Patch("/products/:id", timeout.NewWithContext(productController.Patch, requestTimeout))
+	app.Delete("/products/:id", timeout.NewWithContext(productController.Delete, requestTimeout))
+	app.Post("/products/:id/stock/adjust", timeout.NewWithContext(productController.AdjustStock, requestTimeout))
+	app.Get("/products/:id/movements", timeout.NewWithContext(productController.ListMovements, requestTimeout))
+	if cfg.ProfilingAdmin {
+		app.Get("/admin/profiling", timeout.NewWithContext(profilingController.Summary, requestTimeout))
+	}
+	app.Get("/metrics", m.Handler())
 
-	return app
+	return &Application{App: app, profilingService: profilingService, conns: conns, tracing: tracer, recovering: recovering}
 }
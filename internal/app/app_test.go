@@ -0,0 +1,302 @@
+package app
+
+import (
+	"CRUD-Go-Hexa-MongoDB/pkg/config"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// response mirrors utils.ServiceResponse with a raw payload
+type response struct {
+	Code      int
+	Message   string
+	Data      json.RawMessage
+	RequestID string
+}
+
+// discard drops the log output of tests
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func doRequest(t *testing.T, app *Application, method, path, contentType, body string, headers map[string]string) (response, http.Header) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set(fiber.HeaderContentType, contentType)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var decoded response
+	require.NoError(t, json.Unmarshal(raw, &decoded), string(raw))
+	assert.Equal(t, resp.StatusCode, decoded.Code)
+	return decoded, resp.Header
+}
+
+func TestProductLifecycle(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		app := New(context.Background(), &config.Config{ProductRepository: config.BackendMemory, ProfilingRepository: config.BackendMemory, ProfilingAdmin: true}, discard)
+		testProductLifecycle(t, app)
+		assert.NoError(t, app.Close(context.Background()))
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		cfg := &config.Config{
+			SQLitePath:          filepath.Join(t.TempDir(), "hexa.db"),
+			ProductRepository:   config.BackendSQLite,
+			ProfilingRepository: config.BackendSQLite,
+			ProfilingAdmin:      true,
+		}
+		app := New(context.Background(), cfg, discard)
+		testProductLifecycle(t, app)
+		assert.NoError(t, app.Close(context.Background()))
+
+		// The database is closed once the application is, and readiness notices
+		_, err := app.conns.sqlite.Exec("SELECT 1")
+		assert.Error(t, err)
+		ready, _ := doRequest(t, app, "GET", "/readyz", "", "", nil)
+		assert.Equal(t, http.StatusServiceUnavailable, ready.Code)
+		assert.Contains(t, string(ready.Data), `"name":"sqlite","status":"down","critical":true`)
+	})
+}
+
+func TestDegradedProfiling(t *testing.T) {
+	// Nothing listens on port 1, so the profiling store cannot be opened
+	app := New(context.Background(), &config.Config{
+		PostgresHost:        "127.0.0.1",
+		PostgresPort:        1,
+		ConnectAttempts:     2,
+		ConnectBackoff:      time.Millisecond,
+		ConnectTimeout:      time.Second,
+		ProfilingOptional:   true,
+		ProfilingAdmin:      true,
+		ProductRepository:   config.BackendMemory,
+		ProfilingRepository: config.BackendPostgres,
+	}, discard)
+	defer app.Close(context.Background())
+
+	// Products are still served
+	created, _ := doRequest(t, app, "POST", "/products", fiber.MIMEApplicationJSON, `{"name": "Widget", "stock": 1}`, nil)
+	assert.Equal(t, http.StatusCreated, created.Code)
+
+	ready, _ := doRequest(t, app, "GET", "/readyz", "", "", nil)
+	assert.Equal(t, http.StatusOK, ready.Code)
+	assert.Contains(t, string(ready.Data), `"status":"degraded"`)
+	assert.Contains(t, string(ready.Data), `"name":"profiling","status":"down","critical":false`)
+
+	summary, _ := doRequest(t, app, "GET", "/admin/profiling", "", "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, summary.Code)
+}
+
+func TestRecoveredStoreExportsPoolStats(t *testing.T) {
+	// SQLite cannot create the file until its directory exists
+	dir := filepath.Join(t.TempDir(), "later")
+	app := New(context.Background(), &config.Config{
+		SQLitePath:          filepath.Join(dir, "hexa.db"),
+		ConnectAttempts:     1,
+		ConnectBackoff:      time.Millisecond,
+		ConnectBackoffMax:   5 * time.Millisecond,
+		ProfilingOptional:   true,
+		ProductRepository:   config.BackendMemory,
+		ProfilingRepository: config.BackendSQLite,
+	}, discard)
+	defer app.Close(context.Background())
+
+	require.NoError(t, os.Mkdir(dir, 0o700))
+	require.Eventually(t, func() bool { return app.recovering.Check(context.Background()) == nil }, 5*time.Second, time.Millisecond)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil), -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	scraped, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(scraped), `go_sql_max_open_connections{db_name="sqlite"} 1`)
+}
+
+func TestProfilingAdminIsOffByDefault(t *testing.T) {
+	app := New(context.Background(), &config.Config{ProductRepository: config.BackendMemory, ProfilingRepository: config.BackendMemory}, discard)
+	defer app.Close(context.Background())
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/profiling", nil), -1)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestTracingToFile(t *testing.T) {
+	traces := filepath.Join(t.TempDir(), "traces.json")
+	app := New(context.Background(), &config.Config{
+		ProductRepository:   config.BackendMemory,
+		ProfilingRepository: config.BackendMemory,
+		TracingExporter:     "file",
+		TracingFile:         traces,
+		TracingSampleRate:   1,
+	}, discard)
+
+	created, _ := doRequest(t, app, "POST", "/products", fiber.MIMEApplicationJSON, `{"name": "Widget", "stock": 1}`, nil)
+	require.Equal(t, http.StatusCreated, created.Code)
+	// Closing exports the spans still buffered, even once draining has used up ctx
+	spent, cancel := context.WithCancel(context.Background())
+	cancel()
+	app.Close(spent)
+
+	written, err := os.ReadFile(traces)
+	require.NoError(t, err)
+	for _, name := range []string{"POST /products", "ProductService.Create", "transactor.WithinTransaction", "products.Create"} {
+		assert.Contains(t, string(written), `"Name":"`+name+`"`)
+	}
+}
+
+func testProductLifecycle(t *testing.T, app *Application) {
+	alive, _ := doRequest(t, app, "GET", "/healthz", "", "", nil)
+	assert.Equal(t, http.StatusOK, alive.Code)
+	ready, _ := doRequest(t, app, "GET", "/readyz", "", "", nil)
+	assert.Equal(t, http.StatusOK, ready.Code)
+	assert.Contains(t, string(ready.Data), `"status":"up"`)
+
+	// Create from JSON and from a form
+	created, headers := doRequest(t, app, "POST", "/products", fiber.MIMEApplicationJSON, `{"name": "Widget", "stock": 10}`, nil)
+	require.Equal(t, http.StatusCreated, created.Code)
+	assert.Equal(t, `"1"`, headers.Get(fiber.HeaderETag))
+
+	var widget struct {
+		ID      string `json:"id"`
+		Stock   int    `json:"stock"`
+		Version int64  `json:"version"`
+	}
+	require.NoError(t, json.Unmarshal(created.Data, &widget))
+
+	formCreated, _ := doRequest(t, app, "POST", "/products", fiber.MIMEApplicationForm, "name=Gadget&stock=3", nil)
+	assert.Equal(t, http.StatusCreated, formCreated.Code)
+
+	invalid, _ := doRequest(t, app, "POST", "/products", fiber.MIMEApplicationJSON, `{"name": ""}`, nil)
+	assert.Equal(t, http.StatusBadRequest, invalid.Code)
+
+	unsupported, _ := doRequest(t, app, "POST", "/products", fiber.MIMETextPlain, "Widget", nil)
+	assert.Equal(t, http.StatusUnsupportedMediaType, unsupported.Code)
+
+	// Read back with its ETag
+	found, headers := doRequest(t, app, "GET", "/products/"+widget.ID, "", "", nil)
+	assert.Equal(t, http.StatusOK, found.Code)
+	assert.Equal(t, `"1"`, headers.Get(fiber.HeaderETag))
+
+	// Replace, then try again with the stale ETag
+	replaced, headers := doRequest(t, app, "PUT", "/products/"+widget.ID, fiber.MIMEApplicationJSON,
+		`{"name": "Widget", "stock": 8}`, map[string]string{fiber.HeaderIfMatch: `"1"`})
+	assert.Equal(t, http.StatusOK, replaced.Code)
+	assert.Equal(t, `"2"`, headers.Get(fiber.HeaderETag))
+
+	stale, _ := doRequest(t, app, "PUT", "/products/"+widget.ID, fiber.MIMEApplicationJSON,
+		`{"name": "Widget", "stock": 1}`, map[string]string{fiber.HeaderIfMatch: `"1"`})
+	assert.Equal(t, http.StatusPreconditionFailed, stale.Code)
+
+	// Patch only the name
+	patched, headers := doRequest(t, app, "PATCH", "/products/"+widget.ID, "application/merge-patch+json",
+		`{"name": "Widget XL"}`, map[string]string{fiber.HeaderIfMatch: `"2"`})
+	assert.Equal(t, http.StatusOK, patched.Code)
+	assert.Equal(t, `"3"`, headers.Get(fiber.HeaderETag))
+	assert.Contains(t, string(patched.Data), `"stock":8`)
+
+	// Adjust stock, refusing to go negative
+	adjusted, _ := doRequest(t, app, "POST", "/products/"+widget.ID+"/stock/adjust", fiber.MIMEApplicationJSON,
+		`{"delta": -5, "reason": "shipment"}`, map[string]string{"X-Actor": "warehouse-1"})
+	assert.Equal(t, http.StatusOK, adjusted.Code)
+
+	negative, _ := doRequest(t, app, "POST", "/products/"+widget.ID+"/stock/adjust", fiber.MIMEApplicationJSON,
+		`{"delta": -5, "reason": "shipment"}`, nil)
+	assert.Equal(t, http.StatusConflict, negative.Code)
+
+	// The ledger explains every change, newest first
+	movements, _ := doRequest(t, app, "GET", "/products/"+widget.ID+"/movements", "", "", nil)
+	require.Equal(t, http.StatusOK, movements.Code)
+	var ledger struct {
+		Items []struct {
+			Delta  int    `json:"delta"`
+			Reason string `json:"reason"`
+			Actor  string `json:"actor"`
+		} `json:"items"`
+		Balance int `json:"balance"`
+	}
+	require.NoError(t, json.Unmarshal(movements.Data, &ledger))
+	require.Len(t, ledger.Items, 3)
+	assert.Equal(t, -5, ledger.Items[0].Delta)
+	assert.Equal(t, "warehouse-1", ledger.Items[0].Actor)
+	assert.Equal(t, "product updated", ledger.Items[1].Reason)
+	assert.Equal(t, 3, ledger.Balance)
+
+	// Page through the catalog
+	page, _ := doRequest(t, app, "GET", "/products?limit=1&sort=name", "", "", nil)
+	assert.Equal(t, http.StatusOK, page.Code)
+	var listing struct {
+		Items      []json.RawMessage `json:"items"`
+		Total      int               `json:"total"`
+		NextCursor string            `json:"next_cursor"`
+	}
+	require.NoError(t, json.Unmarshal(page.Data, &listing))
+	assert.Len(t, listing.Items, 1)
+	assert.Equal(t, 2, listing.Total)
+	require.NotEmpty(t, listing.NextCursor)
+
+	next, _ := doRequest(t, app, "GET", "/products?limit=1&sort=name&cursor="+listing.NextCursor, "", "", nil)
+	listing.NextCursor = ""
+	require.NoError(t, json.Unmarshal(next.Data, &listing))
+	assert.Len(t, listing.Items, 1)
+	assert.Empty(t, listing.NextCursor)
+
+	// Delete honors If-Match too
+	deleted, _ := doRequest(t, app, "DELETE", "/products/"+widget.ID, "", "", map[string]string{fiber.HeaderIfMatch: `"4"`})
+	assert.Equal(t, http.StatusOK, deleted.Code)
+
+	// Errors quote the request ID, whether the caller chose it or not
+	missing, headers := doRequest(t, app, "GET", "/products/"+widget.ID, "", "", map[string]string{fiber.HeaderXRequestID: "incident-42"})
+	assert.Equal(t, http.StatusNotFound, missing.Code)
+	assert.Equal(t, "incident-42", missing.RequestID)
+	assert.Equal(t, "incident-42", headers.Get(fiber.HeaderXRequestID))
+
+	missing, headers = doRequest(t, app, "GET", "/products/"+widget.ID, "", "", nil)
+	assert.NotEmpty(t, missing.RequestID)
+	assert.Equal(t, missing.RequestID, headers.Get(fiber.HeaderXRequestID))
+
+	summary, _ := doRequest(t, app, "GET", "/admin/profiling?window=5m", "", "", nil)
+	assert.Equal(t, http.StatusOK, summary.Code)
+
+	// Metrics cover the requests above and the repository calls they made
+	resp, err := app.Test(httptest.NewRequest("GET", "/metrics", nil), -1)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	metrics, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(metrics), `hexa_http_request_duration_seconds_count{method="POST",route="/products",status="201"}`)
+	assert.Contains(t, string(metrics), `operation="adjust_stock",outcome="ok",repository="products"`)
+	assert.Contains(t, string(metrics), "hexa_profiling_queue_depth")
+}
+
+func TestOpeningStopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Preparing the schema runs under the connection context too
+	conns := newConnections(ctx, &config.Config{SQLitePath: filepath.Join(t.TempDir(), "hexa.db")}, discard)
+	_, err := conns.SQLite()
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,225 @@
+package app
+
+import (
+	memoryRepo "CRUD-Go-Hexa-MongoDB/internal/adapters/repository/memory"
+	mongoRepo "CRUD-Go-Hexa-MongoDB/internal/adapters/repository/mongo"
+	postgreSQLRepo "CRUD-Go-Hexa-MongoDB/internal/adapters/repository/postgresql"
+	sqliteRepo "CRUD-Go-Hexa-MongoDB/internal/adapters/repository/sqlite"
+	"CRUD-Go-Hexa-MongoDB/internal/adapters/tracing"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/services"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"CRUD-Go-Hexa-MongoDB/pkg/config"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/lib/pq"
+	mongoDriver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// connections opens each database at most once, on first use, so adapters of
+// different ports can share a backend. It is not safe for concurrent use; after
+// startup only the profiling recovery loop opens databases, and it is stopped
+// before Close
+type connections struct {
+	// ctx cancels every attempt to open a database, including the recovery loop's
+	ctx      context.Context
+	cfg      *config.Config
+	logger   *slog.Logger
+	postgres *sql.DB
+	sqlite   *sql.DB
+	mongo    *mongoDriver.Client
+	memory   *memoryRepo.Store
+	// onOpenSQL, when set, sees every SQL database once it is open, including one
+	// the profiling recovery loop opens long after startup
+	onOpenSQL func(name string, db *sql.DB)
+	// closers release the opened databases, in the order they were opened
+	closers []func(ctx context.Context) error
+}
+
+func newConnections(ctx context.Context, cfg *config.Config, logger *slog.Logger) *connections {
+	return &connections{ctx: ctx, cfg: cfg, logger: logger}
+}
+
+// connectContext bounds a single attempt to reach a database server and prepare it,
+// so a server that accepts connections but never answers cannot stall startup
+func (c *connections) connectContext() (context.Context, context.CancelFunc) {
+	if c.cfg.ConnectTimeout <= 0 {
+		return context.WithCancel(c.ctx)
+	}
+	return context.WithTimeout(c.ctx, c.cfg.ConnectTimeout)
+}
+
+func (c *connections) Postgres() (*sql.DB, error) {
+	if c.postgres != nil {
+		return c.postgres, nil
+	}
+
+	//Connect to PostgreSQL, tracing each statement
+	connector, err := pq.NewConnector(c.cfg.PostgresURL().Reveal())
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(tracing.SQLConnector(connector, semconv.DBSystemPostgreSQL))
+	if c.cfg.PostgresMaxOpenConns > 0 {
+		db.SetMaxOpenConns(c.cfg.PostgresMaxOpenConns)
+	}
+	if c.cfg.PostgresMaxIdleConns > 0 {
+		db.SetMaxIdleConns(c.cfg.PostgresMaxIdleConns)
+	}
+	db.SetConnMaxLifetime(c.cfg.PostgresConnMaxLifetime)
+
+	ctx, cancel := c.connectContext()
+	defer cancel()
+	err = db.PingContext(ctx)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	err = prepareSchema(ctx, db, c.cfg.PostgresMigrate, c.logger)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	c.postgres = db
+	c.closers = append(c.closers, func(context.Context) error { return db.Close() })
+	c.opened(config.BackendPostgres, db)
+	return db, nil
+}
+
+func (c *connections) Mongo() (*mongoDriver.Database, error) {
+	if c.mongo != nil {
+		return c.mongo.Database(c.cfg.DBName), nil
+	}
+
+	//Connect to MongoDB, tracing each command with its collection
+	clientOptions := options.Client().ApplyURI(c.cfg.MongoURI.Reveal()).SetMonitor(otelmongo.NewMonitor())
+	if c.cfg.MongoMaxPoolSize > 0 {
+		clientOptions.SetMaxPoolSize(uint64(c.cfg.MongoMaxPoolSize))
+	}
+	ctx, cancel := c.connectContext()
+	defer cancel()
+	client, err := mongoDriver.Connect(ctx, clientOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	//Ping to MongoDB
+	err = client.Ping(ctx, nil)
+	if err != nil {
+		client.Disconnect(context.Background())
+		return nil, err
+	}
+
+	c.mongo = client
+	c.closers = append(c.closers, client.Disconnect)
+	return client.Database(c.cfg.DBName), nil
+}
+
+func (c *connections) SQLite() (*sql.DB, error) {
+	if c.sqlite != nil {
+		return c.sqlite, nil
+	}
+
+	ctx, cancel := c.connectContext()
+	defer cancel()
+	connector := tracing.SQLConnector(sqliteRepo.NewConnector(c.cfg.SQLitePath), semconv.DBSystemSqlite)
+	db, err := sqliteRepo.OpenDB(ctx, connector)
+	if err != nil {
+		return nil, err
+	}
+
+	c.sqlite = db
+	c.closers = append(c.closers, func(context.Context) error { return db.Close() })
+	c.opened(config.BackendSQLite, db)
+	return db, nil
+}
+
+func (c *connections) opened(name string, db *sql.DB) {
+	if c.onOpenSQL != nil {
+		c.onOpenSQL(name, db)
+	}
+}
+
+func (c *connections) Memory() *memoryRepo.Store {
+	if c.memory == nil {
+		c.memory = memoryRepo.NewStore()
+	}
+	return c.memory
+}
+
+// healthChecks lists a check for every opened database. Only the database behind
+// the products is critical, the service keeps working without profiling
+func (c *connections) healthChecks() []services.HealthCheck {
+	var checks []services.HealthCheck
+	for _, name := range []string{config.BackendPostgres, config.BackendMongo, config.BackendSQLite} {
+		if checker := c.healthChecker(name); checker != nil {
+			checks = append(checks, services.HealthCheck{
+				Name:     name,
+				Checker:  checker,
+				Critical: name == c.cfg.ProductRepository,
+			})
+		}
+	}
+	return checks
+}
+
+// healthChecker returns the checker of the named database, or nil while it is not open
+func (c *connections) healthChecker(name string) ports.IHealthChecker {
+	switch {
+	case name == config.BackendPostgres && c.postgres != nil:
+		return postgreSQLRepo.NewHealthChecker(c.postgres)
+	case name == config.BackendMongo && c.mongo != nil:
+		return mongoRepo.NewHealthChecker(c.mongo)
+	case name == config.BackendSQLite && c.sqlite != nil:
+		return sqliteRepo.NewHealthChecker(c.sqlite)
+	}
+	return nil
+}
+
+// Close closes the opened databases, most recently opened first
+func (c *connections) Close(ctx context.Context) error {
+	var errs []error
+	for i := len(c.closers) - 1; i >= 0; i-- {
+		errs = append(errs, c.closers[i](ctx))
+	}
+	c.closers = nil
+	return errors.Join(errs...)
+}
+
+// prepareSchema applies the startup migration policy to a freshly opened PostgreSQL database
+func prepareSchema(ctx context.Context, db *sql.DB, policy string, logger *slog.Logger) error {
+	migrator, err := postgreSQLRepo.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch policy {
+	case config.MigrateOff:
+		return nil
+	case config.MigrateCheck:
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d pending PostgreSQL migrations starting at %s, run \"migrate up\" or set POSTGRES_MIGRATE=%s",
+				len(pending), pending[0], config.MigrateAuto)
+		}
+		return nil
+	case config.MigrateAuto, "":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			logger.Info("applied PostgreSQL migration", "migration", migration.String())
+		}
+		return err
+	}
+	return fmt.Errorf("unknown POSTGRES_MIGRATE policy %q, want %s, %s or %s", policy, config.MigrateAuto, config.MigrateCheck, config.MigrateOff)
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	postgreSQLRepo "CRUD-Go-Hexa-MongoDB/internal/adapters/repository/postgresql"
+	"CRUD-Go-Hexa-MongoDB/pkg/config"
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+)
+
+// Migrate runs a PostgreSQL migration command: "up", "down [steps]" or "status".
+// down reverts one migration unless told otherwise
+func Migrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up|down [steps]|status")
+	}
+
+	// Leave the schema alone while connecting, the command decides what to do
+	migrateCfg := *cfg
+	migrateCfg.PostgresMigrate = config.MigrateOff
+	db, err := newConnections(context.Background(), &migrateCfg, slog.Default()).Postgres()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := postgreSQLRepo.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Println("applied", migration)
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number, got %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		for _, migration := range reverted {
+			fmt.Println("reverted", migration)
+		}
+		return err
+	case "status":
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		for _, migration := range pending {
+			fmt.Println("pending", migration)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown migrate command %q, want up, down or status", args[0])
+}
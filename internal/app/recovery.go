@@ -0,0 +1,119 @@
+package app
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// recoveringProfilingStore stands in for a profiling store that was down at startup.
+// Profiling is disabled, calls fail as unavailable, until a background loop manages
+// to open the store; from then on calls go through to it
+type recoveringProfilingStore struct {
+	open    func() (ports.IProfilingRepository, error)
+	checker func() ports.IHealthChecker
+	logger  *slog.Logger
+
+	mu      sync.RWMutex
+	repo    ports.IProfilingRepository
+	lastErr error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newRecoveringProfilingStore starts retrying open with b's delays until it succeeds,
+// ctx is done or Close is called. checker, called once the store is open, returns its
+// health checker
+func newRecoveringProfilingStore(ctx context.Context, open func() (ports.IProfilingRepository, error), checker func() ports.IHealthChecker,
+	startErr error, b backoff, logger *slog.Logger) *recoveringProfilingStore {
+	s := &recoveringProfilingStore{
+		open:    open,
+		checker: checker,
+		logger:  logger,
+		lastErr: startErr,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go s.run(ctx, b)
+	return s
+}
+
+func (s *recoveringProfilingStore) run(ctx context.Context, b backoff) {
+	defer close(s.done)
+
+	timer := time.NewTimer(b.delay(1))
+	defer timer.Stop()
+	for failures := 1; ; failures++ {
+		select {
+		case <-s.stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		repo, err := s.open()
+		s.mu.Lock()
+		s.repo, s.lastErr = repo, err
+		s.mu.Unlock()
+
+		if err == nil {
+			s.logger.Info("profiling store recovered, profiling enabled")
+			return
+		}
+		s.logger.Debug("profiling store still unavailable", "error", err)
+		timer.Reset(b.delay(failures + 1))
+	}
+}
+
+// current returns the opened store, or an unavailable error while there is none
+func (s *recoveringProfilingStore) current() (ports.IProfilingRepository, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.repo == nil {
+		return nil, errs.Wrap(errs.KindUnavailable, s.lastErr, "profiling store unavailable")
+	}
+	return s.repo, nil
+}
+
+func (s *recoveringProfilingStore) CreateMany(ctx context.Context, records []models.Profiling) error {
+	repo, err := s.current()
+	if err != nil {
+		return err
+	}
+	return repo.CreateMany(ctx, records)
+}
+
+func (s *recoveringProfilingStore) Summarize(ctx context.Context, query models.ProfilingQuery) ([]models.RouteStats, error) {
+	repo, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+	return repo.Summarize(ctx, query)
+}
+
+// Check reports the store down until it is open, then checks its database
+func (s *recoveringProfilingStore) Check(ctx context.Context) error {
+	if _, err := s.current(); err != nil {
+		return err
+	}
+	if checker := s.checker(); checker != nil {
+		return checker.Check(ctx)
+	}
+	return nil
+}
+
+// Close stops the background loop, so nothing opens a database after the others are closed
+func (s *recoveringProfilingStore) Close() {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	<-s.done
+}
@@ -0,0 +1,146 @@
+package app
+
+import (
+	memoryRepo "CRUD-Go-Hexa-MongoDB/internal/adapters/repository/memory"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	b := backoff{Initial: 100 * time.Millisecond, Max: time.Second}
+	var delays []time.Duration
+	for failures := 1; failures <= 6; failures++ {
+		delays = append(delays, b.delay(failures))
+	}
+	assert.Equal(t, []time.Duration{
+		100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second,
+	}, delays)
+
+	// A zero backoff still waits, so retrying cannot spin
+	assert.Equal(t, minDelay, backoff{}.delay(1))
+}
+
+func TestRetry(t *testing.T) {
+	b := backoff{Attempts: 3, Initial: time.Millisecond, Max: time.Millisecond}
+	refused := errors.New("connection refused")
+
+	t.Run("until it succeeds", func(t *testing.T) {
+		calls := 0
+		value, err := retry(context.Background(), b, discard, "store", func() (int, error) {
+			calls++
+			if calls < 3 {
+				return 0, refused
+			}
+			return 42, nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 42, value)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("gives up after the last attempt", func(t *testing.T) {
+		calls := 0
+		_, err := retry(context.Background(), b, discard, "store", func() (int, error) {
+			calls++
+			return 0, refused
+		})
+		assert.ErrorIs(t, err, refused)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("stops waiting when ctx is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		_, err := retry(ctx, backoff{Attempts: 3, Initial: time.Hour}, discard, "store", func() (int, error) {
+			calls++
+			cancel()
+			return 0, refused
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, err, refused)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("stops at permanent errors", func(t *testing.T) {
+		calls := 0
+		_, err := retry(context.Background(), b, discard, "store", func() (int, error) {
+			calls++
+			return 0, permanentError{refused}
+		})
+		assert.ErrorIs(t, err, refused)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestRecoveringProfilingStore(t *testing.T) {
+	b := backoff{Initial: time.Millisecond, Max: 5 * time.Millisecond}
+	refused := errors.New("connection refused")
+	record := models.Profiling{ID: uuid.New(), APICall: "GET /products", Timestamp: time.Now()}
+
+	t.Run("recovers once the store opens", func(t *testing.T) {
+		var attempts atomic.Int32
+		release := make(chan struct{})
+		store := newRecoveringProfilingStore(context.Background(), func() (ports.IProfilingRepository, error) {
+			attempts.Add(1)
+			select {
+			case <-release:
+				return memoryRepo.NewProfilingRepository(0), nil
+			default:
+				return nil, refused
+			}
+		}, func() ports.IHealthChecker { return nil }, refused, b, discard)
+		defer store.Close()
+
+		// Profiling is disabled while the store is down
+		err := store.CreateMany(context.Background(), []models.Profiling{record})
+		assert.Equal(t, errs.KindUnavailable, errs.KindOf(err))
+		assert.ErrorIs(t, err, refused)
+		assert.Error(t, store.Check(context.Background()))
+		require.Eventually(t, func() bool { return attempts.Load() >= 2 }, time.Second, time.Millisecond)
+
+		close(release)
+		require.Eventually(t, func() bool { return store.Check(context.Background()) == nil }, time.Second, time.Millisecond)
+		assert.NoError(t, store.CreateMany(context.Background(), []models.Profiling{record}))
+	})
+
+	t.Run("close stops retrying", func(t *testing.T) {
+		var attempts atomic.Int32
+		store := newRecoveringProfilingStore(context.Background(), func() (ports.IProfilingRepository, error) {
+			attempts.Add(1)
+			return nil, refused
+		}, func() ports.IHealthChecker { return nil }, refused, b, discard)
+
+		store.Close()
+		// Close waits for the loop, so nothing can open the store afterwards
+		select {
+		case <-store.done:
+		default:
+			t.Fatal("Close returned before the retry loop stopped")
+		}
+	})
+
+	t.Run("cancelling ctx stops retrying", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		store := newRecoveringProfilingStore(ctx, func() (ports.IProfilingRepository, error) {
+			return nil, refused
+		}, func() ports.IHealthChecker { return nil }, refused, backoff{Initial: time.Hour}, discard)
+		defer store.Close()
+
+		cancel()
+		select {
+		case <-store.done:
+		case <-time.After(time.Second):
+			t.Fatal("the retry loop kept waiting after ctx was cancelled")
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	memoryRepo "CRUD-Go-Hexa-MongoDB/internal/adapters/repository/memory"
+	mongoRepo "CRUD-Go-Hexa-MongoDB/internal/adapters/repository/mongo"
+	postgreSQLRepo "CRUD-Go-Hexa-MongoDB/internal/adapters/repository/postgresql"
+	sqliteRepo "CRUD-Go-Hexa-MongoDB/internal/adapters/repository/sqlite"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"CRUD-Go-Hexa-MongoDB/pkg/config"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// productStore bundles the product adapters. They always come from one backend
+// because the transactor must cover both the products and their ledger
+type productStore struct {
+	products   ports.IProductRepository
+	movements  ports.IStockMovementRepository
+	transactor ports.ITransactor
+}
+
+type productStoreFactory func(conns *connections) (productStore, error)
+
+type profilingStoreFactory func(conns *connections) (ports.IProfilingRepository, error)
+
+// productStores lists the adapters available for the product port by config name
+var productStores = map[string]productStoreFactory{
+	config.BackendPostgres: func(conns *connections) (productStore, error) {
+		db, err := conns.Postgres()
+		if err != nil {
+			return productStore{}, err
+		}
+		return productStore{
+			products:   postgreSQLRepo.NewProductRepository(db),
+			movements:  postgreSQLRepo.NewStockMovementRepository(db),
+			transactor: postgreSQLRepo.NewTransactor(db),
+		}, nil
+	},
+	config.BackendMongo: func(conns *connections) (productStore, error) {
+		db, err := conns.Mongo()
+		if err != nil {
+			return productStore{}, err
+		}
+		ctx, cancel := conns.connectContext()
+		defer cancel()
+		if err := mongoRepo.EnsureIndexes(ctx, db); err != nil {
+			return productStore{}, err
+		}
+		// Product writes run in transactions, which a standalone server rejects, so
+		// refuse to start rather than fail every write
+		transactions, err := mongoRepo.SupportsTransactions(ctx, db)
+		if err != nil {
+			return productStore{}, err
+		}
+		if !transactions {
+			return productStore{}, permanentError{errors.New("the mongo product store needs a replica set or sharded cluster " +
+				"for its transactions, but MONGO_URI points at a standalone server: start mongod with --replSet " +
+				"and run rs.initiate(), or choose another PRODUCT_REPOSITORY")}
+		}
+		return productStore{
+			products:   mongoRepo.NewProductRepository(db),
+			movements:  mongoRepo.NewStockMovementRepository(db),
+			transactor: mongoRepo.NewTransactor(db),
+		}, nil
+	},
+	config.BackendSQLite: func(conns *connections) (productStore, error) {
+		db, err := conns.SQLite()
+		if err != nil {
+			return productStore{}, err
+		}
+		return productStore{
+			products:   sqliteRepo.NewProductRepository(db),
+			movements:  sqliteRepo.NewStockMovementRepository(db),
+			transactor: sqliteRepo.NewTransactor(db),
+		}, nil
+	},
+	config.BackendMemory: func(conns *connections) (productStore, error) {
+		store := conns.Memory()
+		return productStore{
+			products:   memoryRepo.NewProductRepository(store),
+			movements:  memoryRepo.NewStockMovementRepository(store),
+			transactor: memoryRepo.NewTransactor(store),
+		}, nil
+	},
+}
+
+// profilingStores lists the adapters available for the profiling port by config name
+var profilingStores = map[string]profilingStoreFactory{
+	config.BackendMongo: func(conns *connections) (ports.IProfilingRepository, error) {
+		db, err := conns.Mongo()
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := conns.connectContext()
+		defer cancel()
+		if err := mongoRepo.EnsureProfilingCollection(ctx, db, conns.cfg.ProfilingTTL); err != nil {
+			return nil, err
+		}
+		return mongoRepo.NewProfilingRepository(db), nil
+	},
+	config.BackendPostgres: func(conns *connections) (ports.IProfilingRepository, error) {
+		db, err := conns.Postgres()
+		if err != nil {
+			return nil, err
+		}
+		return postgreSQLRepo.NewProfilingRepository(db), nil
+	},
+	config.BackendSQLite: func(conns *connections) (ports.IProfilingRepository, error) {
+		db, err := conns.SQLite()
+		if err != nil {
+			return nil, err
+		}
+		return sqliteRepo.NewProfilingRepository(db), nil
+	},
+	config.BackendMemory: func(conns *connections) (ports.IProfilingRepository, error) {
+		return memoryRepo.NewProfilingRepository(conns.cfg.ProfilingTTL), nil
+	},
+}
+
+func newProductStore(name string, conns *connections) (productStore, error) {
+	factory, ok := productStores[name]
+	if !ok {
+		return productStore{}, permanentError{fmt.Errorf("unknown product repository %q, available: %v", name, registered(productStores))}
+	}
+	return factory(conns)
+}
+
+func newProfilingStore(name string, conns *connections) (ports.IProfilingRepository, error) {
+	factory, ok := profilingStores[name]
+	if !ok {
+		return nil, permanentError{fmt.Errorf("unknown profiling repository %q, available: %v", name, registered(profilingStores))}
+	}
+	return factory(conns)
+}
+
+func registered[F any](factories map[string]F) []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// minDelay is the shortest wait between attempts, so a zero backoff cannot turn
+// retrying into a busy loop against a database that is down
+const minDelay = 10 * time.Millisecond
+
+// backoff spaces out attempts to open a database. The delay starts at Initial and
+// doubles after every failure, up to Max
+type backoff struct {
+	// Attempts is how many times to try in total, at least once
+	Attempts int
+	Initial  time.Duration
+	Max      time.Duration
+}
+
+// delay returns how long to wait after the given number of consecutive failures
+func (b backoff) delay(failures int) time.Duration {
+	delay := b.Initial
+	for i := 1; i < failures && delay < b.Max; i++ {
+		delay *= 2
+	}
+	if b.Max > 0 && delay > b.Max {
+		delay = b.Max
+	}
+	return max(delay, minDelay)
+}
+
+// permanentError marks failures that retrying cannot fix, such as a misconfiguration
+type permanentError struct {
+	error
+}
+
+func (e permanentError) Unwrap() error {
+	return e.error
+}
+
+// retry calls open until it succeeds, fails permanently, runs out of attempts or ctx
+// is done, logging each failure it retries
+func retry[T any](ctx context.Context, b backoff, logger *slog.Logger, what string, open func() (T, error)) (T, error) {
+	for attempt := 1; ; attempt++ {
+		value, err := open()
+		if err == nil || attempt >= b.Attempts || errors.As(err, new(permanentError)) {
+			return value, err
+		}
+
+		delay := b.delay(attempt)
+		logger.Warn("could not open "+what+", retrying", "attempt", attempt, "of", b.Attempts, "retry_in", delay, "error", err)
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return value, errors.Join(sleepErr, err)
+		}
+	}
+}
+
+// sleep waits for d, returning early with ctx's error when ctx is done first
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
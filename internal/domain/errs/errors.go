@@ -0,0 +1,148 @@
+// Backend-neutral errors returned by repository adapters. Each adapter maps its
+// driver errors into one of these kinds so services never depend on a database package.
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindConflict
+	KindValidation
+	KindUnavailable
+	KindPreconditionFailed
+	// KindCanceled is work abandoned because the caller went away
+	KindCanceled
+	// KindTimeout is work that ran out of time
+	KindTimeout
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not found"
+	case KindConflict:
+		return "conflict"
+	case KindValidation:
+		return "validation"
+	case KindUnavailable:
+		return "unavailable"
+	case KindPreconditionFailed:
+		return "precondition failed"
+	case KindCanceled:
+		return "canceled"
+	case KindTimeout:
+		return "timeout"
+	default:
+		return "internal"
+	}
+}
+
+// Sentinels usable with errors.Is, e.g. errors.Is(err, errs.ErrNotFound)
+var (
+	ErrNotFound    = &Error{Kind: KindNotFound}
+	ErrConflict    = &Error{Kind: KindConflict}
+	ErrValidation  = &Error{Kind: KindValidation}
+	ErrUnavailable = &Error{Kind: KindUnavailable}
+
+	ErrPreconditionFailed = &Error{Kind: KindPreconditionFailed}
+	ErrCanceled           = &Error{Kind: KindCanceled}
+	ErrTimeout            = &Error{Kind: KindTimeout}
+)
+
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Kind.String()
+	}
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports a match on kind, so any *Error matches the sentinel of the same kind
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind
+}
+
+func NotFound(format string, args ...interface{}) error {
+	return &Error{Kind: KindNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+func Conflict(format string, args ...interface{}) error {
+	return &Error{Kind: KindConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+func Validation(format string, args ...interface{}) error {
+	return &Error{Kind: KindValidation, Message: fmt.Sprintf(format, args...)}
+}
+
+func Unavailable(format string, args ...interface{}) error {
+	return &Error{Kind: KindUnavailable, Message: fmt.Sprintf(format, args...)}
+}
+
+func PreconditionFailed(format string, args ...interface{}) error {
+	return &Error{Kind: KindPreconditionFailed, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap attaches a kind to a driver error while keeping it available to errors.As
+func Wrap(kind Kind, err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+// PartialError is a batch write that stored all but Failed of its records
+type PartialError struct {
+	Failed int
+	Err    error
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("%d records not written: %v", e.Failed, e.Err)
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
+}
+
+// Partial reports that failed records of a batch were rejected and the rest stored
+func Partial(failed int, err error) error {
+	return &PartialError{Failed: failed, Err: err}
+}
+
+// KindOf returns the kind of the first *Error in err's chain. Errors that carry
+// no kind, or only KindInternal, are canceled or timed out when the chain holds
+// the matching context error, and internal otherwise
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) && e.Kind != KindInternal {
+		return e.Kind
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return KindCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return KindTimeout
+	}
+	return KindInternal
+}
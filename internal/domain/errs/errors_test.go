@@ -0,0 +1,41 @@
+package errs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorMatching(t *testing.T) {
+	t.Run("matches sentinel of the same kind", func(t *testing.T) {
+		err := fmt.Errorf("service: %w", NotFound("product %d not found", 1))
+
+		assert.True(t, errors.Is(err, ErrNotFound))
+		assert.False(t, errors.Is(err, ErrConflict))
+		assert.Equal(t, KindNotFound, KindOf(err))
+	})
+
+	t.Run("keeps the driver error in the chain", func(t *testing.T) {
+		err := Wrap(KindNotFound, sql.ErrNoRows, "find product")
+
+		assert.True(t, errors.Is(err, sql.ErrNoRows))
+		assert.True(t, errors.Is(err, ErrNotFound))
+		assert.Equal(t, "find product: "+sql.ErrNoRows.Error(), err.Error())
+	})
+
+	t.Run("recognizes context errors without a kind", func(t *testing.T) {
+		assert.Equal(t, KindCanceled, KindOf(fmt.Errorf("query: %w", context.Canceled)))
+		assert.Equal(t, KindTimeout, KindOf(Wrap(KindInternal, context.DeadlineExceeded, "query")))
+		// An explicit kind wins
+		assert.Equal(t, KindUnavailable, KindOf(Wrap(KindUnavailable, context.DeadlineExceeded, "query")))
+	})
+
+	t.Run("treats unknown errors as internal", func(t *testing.T) {
+		assert.Equal(t, KindInternal, KindOf(errors.New("boom")))
+		assert.Nil(t, Wrap(KindConflict, nil, "noop"))
+	})
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type HealthStatus string
+
+const (
+	HealthUp HealthStatus = "up"
+	// HealthDegraded means requests are served, but slowly or without an optional dependency
+	HealthDegraded HealthStatus = "degraded"
+	HealthDown     HealthStatus = "down"
+)
+
+// DependencyHealth is the outcome of checking one dependency
+type DependencyHealth struct {
+	Name   string       `json:"name"`
+	Status HealthStatus `json:"status"`
+	// Critical dependencies being down makes the whole service not ready
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// HealthReport is the overall status together with the status of each dependency
+type HealthReport struct {
+	Status       HealthStatus       `json:"status"`
+	CheckedAt    time.Time          `json:"checked_at"`
+	Dependencies []DependencyHealth `json:"dependencies"`
+}
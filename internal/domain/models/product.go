@@ -1,9 +1,25 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Product struct {
 	ID    uuid.UUID `json:"id"`
 	Name  string    `json:"name"`
 	Stock int       `json:"stock"`
+	// Version starts at 1 and is incremented by every successful update
+	Version   int64     `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ExpectedVersions are the product versions an If-Match precondition accepts,
+// empty accepts any version
+type ExpectedVersions []int64
+
+func (v ExpectedVersions) Allows(version int64) bool {
+	return len(v) == 0 || slices.Contains(v, version)
 }
@@ -0,0 +1,53 @@
+package models
+
+import "github.com/google/uuid"
+
+type ProductSortField string
+
+const (
+	SortByName  ProductSortField = "name"
+	SortByStock ProductSortField = "stock"
+)
+
+// ProductCursor marks the last product of a page for keyset pagination
+type ProductCursor struct {
+	SortBy     ProductSortField `json:"s"`
+	Descending bool             `json:"d,omitempty"`
+	Name       string           `json:"n,omitempty"`
+	Stock      int              `json:"k,omitempty"`
+	ID         uuid.UUID        `json:"i"`
+}
+
+// ProductQuery selects a page of products. Results are always ordered by SortBy then ID,
+// and when After is set only products strictly past that cursor are returned
+type ProductQuery struct {
+	Limit        int
+	Offset       int
+	After        *ProductCursor
+	NameContains string
+	MinStock     *int
+	MaxStock     *int
+	SortBy       ProductSortField
+	Descending   bool
+}
+
+type ProductPage struct {
+	Items      []Product `json:"items"`
+	Total      int64     `json:"total"`
+	Limit      int       `json:"limit"`
+	Offset     int       `json:"offset"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	HasMore    bool      `json:"has_more"`
+}
+
+// CursorAfter builds the cursor pointing just past p for the given ordering
+func CursorAfter(p Product, sortBy ProductSortField, descending bool) ProductCursor {
+	cursor := ProductCursor{SortBy: sortBy, Descending: descending, ID: p.ID}
+	switch sortBy {
+	case SortByStock:
+		cursor.Stock = p.Stock
+	default:
+		cursor.Name = p.Name
+	}
+	return cursor
+}
@@ -0,0 +1,35 @@
+package models
+
+// Request DTOs accepted by the product service. Pointer fields are nil when the client
+// left the field out, which lets validation tell "absent" apart from "empty"
+
+type CreateProductRequest struct {
+	Name  *string `json:"name"`
+	Stock *int    `json:"stock"`
+}
+
+// UpdateProductRequest replaces every editable field of a product (PUT)
+type UpdateProductRequest struct {
+	Name  *string `json:"name"`
+	Stock *int    `json:"stock"`
+}
+
+type PatchFormat string
+
+const (
+	// MergePatch is a JSON Merge Patch document (RFC 7396)
+	MergePatch PatchFormat = "merge-patch"
+	// JSONPatch is a list of JSON Patch operations (RFC 6902)
+	JSONPatch PatchFormat = "json-patch"
+)
+
+// PatchProductRequest carries a raw patch document applied to a product's editable fields
+type PatchProductRequest struct {
+	Format   PatchFormat
+	Document []byte
+}
+
+type AdjustStockRequest struct {
+	Delta  *int   `json:"delta"`
+	Reason string `json:"reason"`
+}
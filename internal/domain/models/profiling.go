@@ -7,8 +7,42 @@ import (
 )
 
 type Profiling struct {
-	ID        uuid.UUID `json:"id"`
-	APICall   string    `json:"method"`
-	Duration  int64     `json:"duration"`
-	Timestamp time.Time `json:"timestamp"`
+	ID uuid.UUID `json:"id" bson:"id"`
+	// APICall is the method and route template, e.g. "GET /products/:id"
+	APICall string `json:"api_call" bson:"apicall"`
+	Method  string `json:"method" bson:"method"`
+	// Route is the matched route template, empty when no route matched
+	Route        string            `json:"route" bson:"route"`
+	PathParams   map[string]string `json:"path_params,omitempty" bson:"path_params,omitempty"`
+	Status       int               `json:"status" bson:"status"`
+	ResponseSize int64             `json:"response_size" bson:"response_size"`
+	// RequestID correlates the record with log lines and error responses
+	RequestID string `json:"request_id,omitempty" bson:"request_id,omitempty"`
+	// Duration is in microseconds
+	Duration  int64     `json:"duration" bson:"duration"`
+	Timestamp time.Time `json:"timestamp" bson:"timestamp"`
+	// Weight is how many requests the record stands for: 1/rate when sampling kept it
+	// by chance, 1 when it is always kept. Zero counts as 1, see SampleWeight
+	Weight float64 `json:"weight" bson:"weight"`
+}
+
+// SampleWeight is the record's Weight, or 1 when it has none
+func (p Profiling) SampleWeight() float64 {
+	if p.Weight <= 0 {
+		return 1
+	}
+	return p.Weight
+}
+
+// ProfilingStats counts what happened to logged records since startup
+type ProfilingStats struct {
+	// Queued records are waiting to be written
+	Queued  int    `json:"queued"`
+	Written uint64 `json:"written"`
+	// Dropped records never reached the store because the queue was full or closed
+	Dropped uint64 `json:"dropped"`
+	// Failed records were part of a batch the store rejected
+	Failed uint64 `json:"failed"`
+	// Skipped records were left out by the sampling rules
+	Skipped uint64 `json:"skipped"`
 }
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// ProfilingQuery selects the records to summarize. Records are grouped per route and
+// per window of Window length starting at From; a zero Window makes one window of the whole range
+type ProfilingQuery struct {
+	From   time.Time
+	To     time.Time
+	Window time.Duration
+	// Method and Route narrow the summary to one endpoint when set
+	Method string
+	Route  string
+}
+
+// WindowStart returns the start of the window t falls in
+func (q ProfilingQuery) WindowStart(t time.Time) time.Time {
+	if q.Window <= 0 {
+		return q.From
+	}
+	return q.From.Add(t.Sub(q.From) / q.Window * q.Window)
+}
+
+// Matches reports whether a record falls in the query's range and endpoint.
+// From is inclusive and To exclusive
+func (q ProfilingQuery) Matches(p Profiling) bool {
+	return !p.Timestamp.Before(q.From) && p.Timestamp.Before(q.To) &&
+		(q.Method == "" || p.Method == q.Method) &&
+		(q.Route == "" || p.Route == q.Route)
+}
+
+// RouteStats summarizes one route over one window. Each record counts for its
+// SampleWeight, so Count and Errors estimate the requests served and the percentiles
+// are not skewed by the records sampling always keeps. Latencies are in microseconds
+type RouteStats struct {
+	WindowStart time.Time `json:"window_start"`
+	Method      string    `json:"method"`
+	Route       string    `json:"route"`
+	Count       int64     `json:"count"`
+	Errors      int64     `json:"errors"`
+	ErrorRate   float64   `json:"error_rate"`
+	P50         int64     `json:"p50"`
+	P95         int64     `json:"p95"`
+	P99         int64     `json:"p99"`
+}
+
+// ProfilingReport is the answer to a profiling query, plus the health of the write pipeline
+type ProfilingReport struct {
+	From     time.Time      `json:"from"`
+	To       time.Time      `json:"to"`
+	Window   string         `json:"window"`
+	Routes   []RouteStats   `json:"routes"`
+	Pipeline ProfilingStats `json:"pipeline"`
+}
+
+// IsError reports whether the request failed on the server side
+func (p Profiling) IsError() bool {
+	return p.Status >= 500
+}
+
+// SummarizeProfiling computes route statistics in memory, for stores that cannot
+// aggregate themselves. Records outside the query are ignored
+func SummarizeProfiling(records []Profiling, query ProfilingQuery) []RouteStats {
+	summarizer := NewProfilingSummarizer(query)
+	for _, p := range records {
+		summarizer.Add(p)
+	}
+	return summarizer.Stats()
+}
+
+// ProfilingSummarizer computes route statistics from records added one at a time,
+// keeping only their durations and weights, so stores can stream records into it
+type ProfilingSummarizer struct {
+	query  ProfilingQuery
+	groups map[summaryKey]*summaryGroup
+}
+
+type summaryKey struct {
+	window        time.Time
+	method, route string
+}
+
+type summaryGroup struct {
+	samples []weightedDuration
+	total   float64
+	errors  float64
+}
+
+type weightedDuration struct {
+	duration int64
+	weight   float64
+}
+
+func NewProfilingSummarizer(query ProfilingQuery) *ProfilingSummarizer {
+	return &ProfilingSummarizer{query: query, groups: map[summaryKey]*summaryGroup{}}
+}
+
+// Add counts p in its window and route, unless it falls outside the query
+func (s *ProfilingSummarizer) Add(p Profiling) {
+	if !s.query.Matches(p) {
+		return
+	}
+	k := summaryKey{s.query.WindowStart(p.Timestamp).UTC(), p.Method, p.Route}
+	group := s.groups[k]
+	if group == nil {
+		group = &summaryGroup{}
+		s.groups[k] = group
+	}
+	weight := p.SampleWeight()
+	group.samples = append(group.samples, weightedDuration{p.Duration, weight})
+	group.total += weight
+	if p.IsError() {
+		group.errors += weight
+	}
+}
+
+// Stats returns the statistics of the records added so far, ordered by SortRouteStats
+func (s *ProfilingSummarizer) Stats() []RouteStats {
+	stats := make([]RouteStats, 0, len(s.groups))
+	for k, group := range s.groups {
+		sort.Slice(group.samples, func(i, j int) bool { return group.samples[i].duration < group.samples[j].duration })
+		stats = append(stats, RouteStats{
+			WindowStart: k.window,
+			Method:      k.method,
+			Route:       k.route,
+			Count:       int64(math.Round(group.total)),
+			Errors:      int64(math.Round(group.errors)),
+			ErrorRate:   group.errors / group.total,
+			P50:         percentile(group.samples, group.total, 0.50),
+			P95:         percentile(group.samples, group.total, 0.95),
+			P99:         percentile(group.samples, group.total, 0.99),
+		})
+	}
+	SortRouteStats(stats)
+	return stats
+}
+
+// percentileTolerance absorbs the rounding of summed fractional weights
+const percentileTolerance = 1e-9
+
+// percentile returns the weighted nearest-rank percentile p, between 0 and 1, of
+// samples sorted by duration: the first duration whose running weight reaches p of
+// the total. With every weight 1 it is the plain nearest rank
+func percentile(sorted []weightedDuration, total, p float64) int64 {
+	var running float64
+	for _, sample := range sorted {
+		running += sample.weight
+		if running >= p*total-percentileTolerance {
+			return sample.duration
+		}
+	}
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[len(sorted)-1].duration
+}
+
+// SortRouteStats orders stats by window, then route, then method
+func SortRouteStats(stats []RouteStats) {
+	sort.Slice(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		if !a.WindowStart.Equal(b.WindowStart) {
+			return a.WindowStart.Before(b.WindowStart)
+		}
+		if a.Route != b.Route {
+			return a.Route < b.Route
+		}
+		return a.Method < b.Method
+	})
+}
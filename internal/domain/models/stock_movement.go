@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StockMovement is an append-only ledger entry recording why a product's stock changed
+type StockMovement struct {
+	ID        uuid.UUID `json:"id"`
+	ProductID uuid.UUID `json:"product_id"`
+	Delta     int       `json:"delta"`
+	// Balance is the product stock right after this movement was applied
+	Balance   int       `json:"balance"`
+	Reason    string    `json:"reason"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MovementQuery selects a page of a product's movements, newest first
+type MovementQuery struct {
+	ProductID uuid.UUID
+	Limit     int
+	Offset    int
+	From      *time.Time
+	To        *time.Time
+}
+
+type MovementPage struct {
+	Items   []StockMovement `json:"items"`
+	Total   int64           `json:"total"`
+	Limit   int             `json:"limit"`
+	Offset  int             `json:"offset"`
+	HasMore bool            `json:"has_more"`
+	// Balance is the stock reconstructed from the ledger as of BalanceAt
+	Balance   int       `json:"balance"`
+	BalanceAt time.Time `json:"balance_at"`
+}
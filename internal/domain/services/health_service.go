@@ -0,0 +1,121 @@
+package services
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"CRUD-Go-Hexa-MongoDB/internal/utils"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Health check defaults, used for zero options
+const (
+	DefaultHealthTimeout       = 2 * time.Second
+	DefaultHealthSlowThreshold = 500 * time.Millisecond
+)
+
+// HealthCheck names a dependency and says whether the service can work without it
+type HealthCheck struct {
+	Name     string
+	Checker  ports.IHealthChecker
+	Critical bool
+}
+
+type HealthOptions struct {
+	// Timeout bounds each check; a dependency that does not answer in time is down
+	Timeout time.Duration
+	// SlowThreshold marks dependencies that answer slower than this as degraded
+	SlowThreshold time.Duration
+}
+
+func (o HealthOptions) withDefaults() HealthOptions {
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultHealthTimeout
+	}
+	if o.SlowThreshold <= 0 {
+		o.SlowThreshold = DefaultHealthSlowThreshold
+	}
+	return o
+}
+
+type HealthService struct {
+	checks  []HealthCheck
+	options HealthOptions
+}
+
+func NewHealthService(checks []HealthCheck, options HealthOptions) *HealthService {
+	return &HealthService{checks: checks, options: options.withDefaults()}
+}
+
+func (s *HealthService) Liveness(ctx context.Context) utils.ServiceResponse {
+	return utils.ServiceResponse{
+		Code:    http.StatusOK,
+		Message: "alive",
+		Data:    models.HealthReport{Status: models.HealthUp, CheckedAt: time.Now(), Dependencies: []models.DependencyHealth{}},
+	}
+}
+
+// Readiness answers 200 while the service is up or degraded, so a slow or optional
+// dependency does not take it out of rotation
+func (s *HealthService) Readiness(ctx context.Context) utils.ServiceResponse {
+	report := s.check(ctx)
+	if report.Status == models.HealthDown {
+		return utils.ServiceResponse{Code: http.StatusServiceUnavailable, Message: "not ready", Data: report}
+	}
+	return utils.ServiceResponse{Code: http.StatusOK, Message: "ready", Data: report}
+}
+
+// check runs every check concurrently, so a hanging dependency costs one timeout rather than one each
+func (s *HealthService) check(ctx context.Context) models.HealthReport {
+	report := models.HealthReport{
+		Status:       models.HealthUp,
+		CheckedAt:    time.Now(),
+		Dependencies: make([]models.DependencyHealth, len(s.checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range s.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Dependencies[i] = s.checkOne(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for _, dependency := range report.Dependencies {
+		switch {
+		case dependency.Status == models.HealthDown && dependency.Critical:
+			report.Status = models.HealthDown
+		case dependency.Status != models.HealthUp && report.Status == models.HealthUp:
+			report.Status = models.HealthDegraded
+		}
+	}
+	return report
+}
+
+func (s *HealthService) checkOne(ctx context.Context, check HealthCheck) models.DependencyHealth {
+	ctx, cancel := context.WithTimeout(ctx, s.options.Timeout)
+	defer cancel()
+
+	startTime := time.Now()
+	err := check.Checker.Check(ctx)
+	latency := time.Since(startTime)
+
+	dependency := models.DependencyHealth{
+		Name:      check.Name,
+		Status:    models.HealthUp,
+		Critical:  check.Critical,
+		LatencyMS: float64(latency.Microseconds()) / 1000,
+	}
+	switch {
+	case err != nil:
+		dependency.Status = models.HealthDown
+		dependency.Error = err.Error()
+	case latency > s.options.SlowThreshold:
+		dependency.Status = models.HealthDegraded
+	}
+	return dependency
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChecker answers after delay, or when ctx is done if hang is set
+type fakeChecker struct {
+	delay time.Duration
+	hang  bool
+	err   error
+}
+
+func (c fakeChecker) Check(ctx context.Context) error {
+	if c.hang {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	time.Sleep(c.delay)
+	return c.err
+}
+
+func TestReadiness(t *testing.T) {
+	options := HealthOptions{Timeout: 50 * time.Millisecond, SlowThreshold: 20 * time.Millisecond}
+	down := fakeChecker{err: errs.Unavailable("connection refused")}
+
+	for _, tc := range []struct {
+		name   string
+		checks []HealthCheck
+		code   int
+		status models.HealthStatus
+	}{
+		{"no dependencies", nil, http.StatusOK, models.HealthUp},
+		{"all up", []HealthCheck{
+			{Name: "postgres", Checker: fakeChecker{}, Critical: true},
+			{Name: "mongo", Checker: fakeChecker{}},
+		}, http.StatusOK, models.HealthUp},
+		{"slow dependency", []HealthCheck{
+			{Name: "postgres", Checker: fakeChecker{delay: 30 * time.Millisecond}, Critical: true},
+		}, http.StatusOK, models.HealthDegraded},
+		{"optional dependency down", []HealthCheck{
+			{Name: "postgres", Checker: fakeChecker{}, Critical: true},
+			{Name: "mongo", Checker: down},
+		}, http.StatusOK, models.HealthDegraded},
+		{"critical dependency down", []HealthCheck{
+			{Name: "postgres", Checker: down, Critical: true},
+			{Name: "mongo", Checker: fakeChecker{delay: 30 * time.Millisecond}},
+		}, http.StatusServiceUnavailable, models.HealthDown},
+		{"critical dependency hangs", []HealthCheck{
+			{Name: "postgres", Checker: fakeChecker{hang: true}, Critical: true},
+		}, http.StatusServiceUnavailable, models.HealthDown},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			response := NewHealthService(tc.checks, options).Readiness(context.Background())
+			assert.Equal(t, tc.code, response.Code)
+
+			report, ok := response.Data.(models.HealthReport)
+			require.True(t, ok)
+			assert.Equal(t, tc.status, report.Status)
+			require.Len(t, report.Dependencies, len(tc.checks))
+			for i, check := range tc.checks {
+				assert.Equal(t, check.Name, report.Dependencies[i].Name)
+				assert.Equal(t, check.Critical, report.Dependencies[i].Critical)
+			}
+		})
+	}
+
+	t.Run("reports each dependency", func(t *testing.T) {
+		response := NewHealthService([]HealthCheck{
+			{Name: "postgres", Checker: fakeChecker{delay: 30 * time.Millisecond}, Critical: true},
+			{Name: "mongo", Checker: down},
+		}, options).Readiness(context.Background())
+
+		report := response.Data.(models.HealthReport)
+		slow, failed := report.Dependencies[0], report.Dependencies[1]
+		assert.Equal(t, models.HealthDegraded, slow.Status)
+		assert.GreaterOrEqual(t, slow.LatencyMS, float64(30))
+		assert.Empty(t, slow.Error)
+		assert.Equal(t, models.HealthDown, failed.Status)
+		assert.Equal(t, "connection refused", failed.Error)
+	})
+}
+
+func TestLiveness(t *testing.T) {
+	// Liveness never looks at dependencies, a restart would not fix them
+	service := NewHealthService([]HealthCheck{{Name: "postgres", Checker: fakeChecker{hang: true}, Critical: true}}, HealthOptions{})
+	response := service.Liveness(context.Background())
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, models.HealthUp, response.Data.(models.HealthReport).Status)
+}
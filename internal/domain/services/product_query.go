@@ -0,0 +1,121 @@
+package services
+
+import (
+	product "CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultPageSize          = 20
+	MaxPageSize              = 100
+	DefaultLowStockThreshold = 10
+)
+
+// parseProductQuery turns raw query parameters into a repository query, collecting every validation error
+func (s *ProductService) parseProductQuery(params map[string]string) (product.ProductQuery, []string) {
+	var arrErrors []string
+
+	query := product.ProductQuery{
+		NameContains: strings.TrimSpace(params["name"]),
+		SortBy:       product.SortByName,
+	}
+
+	query.Limit, query.Offset = parsePagination(params, &arrErrors)
+
+	if sortStr := params["sort"]; sortStr != "" {
+		query.Descending = strings.HasPrefix(sortStr, "-")
+		switch field := product.ProductSortField(strings.TrimPrefix(sortStr, "-")); field {
+		case product.SortByName, product.SortByStock:
+			query.SortBy = field
+		default:
+			arrErrors = append(arrErrors, "Invalid sort, must be one of name, -name, stock, -stock")
+		}
+	}
+
+	query.MinStock = parseStockBound(params["min_stock"], "min_stock", &arrErrors)
+	query.MaxStock = parseStockBound(params["max_stock"], "max_stock", &arrErrors)
+	if query.MinStock != nil && query.MaxStock != nil && *query.MinStock > *query.MaxStock {
+		arrErrors = append(arrErrors, "Invalid stock range, min_stock must not exceed max_stock")
+	}
+
+	if lowStockStr := params["low_stock"]; lowStockStr != "" {
+		lowStock, err := strconv.ParseBool(lowStockStr)
+		if err != nil {
+			arrErrors = append(arrErrors, "Invalid low_stock, must be true or false")
+		} else if lowStock && (query.MaxStock == nil || *query.MaxStock > s.LowStockThreshold) {
+			threshold := s.LowStockThreshold
+			query.MaxStock = &threshold
+		}
+	}
+
+	if cursorStr := params["cursor"]; cursorStr != "" {
+		cursor, err := decodeCursor(cursorStr)
+		switch {
+		case err != nil:
+			arrErrors = append(arrErrors, "Invalid cursor")
+		case cursor.SortBy != query.SortBy || cursor.Descending != query.Descending:
+			arrErrors = append(arrErrors, "Cursor does not match the requested sort")
+		case query.Offset != 0:
+			arrErrors = append(arrErrors, "Cursor and offset cannot be combined")
+		default:
+			query.After = &cursor
+		}
+	}
+
+	return query, arrErrors
+}
+
+// parsePagination reads limit and offset, falling back to the first page of DefaultPageSize
+func parsePagination(params map[string]string, arrErrors *[]string) (limit, offset int) {
+	limit = DefaultPageSize
+
+	if limitStr := params["limit"]; limitStr != "" {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil || value < 1 || value > MaxPageSize {
+			*arrErrors = append(*arrErrors, "Invalid limit, must be a number between 1 and "+strconv.Itoa(MaxPageSize))
+		} else {
+			limit = value
+		}
+	}
+
+	if offsetStr := params["offset"]; offsetStr != "" {
+		value, err := strconv.Atoi(offsetStr)
+		if err != nil || value < 0 {
+			*arrErrors = append(*arrErrors, "Invalid offset, must be a number and not negative")
+		} else {
+			offset = value
+		}
+	}
+
+	return limit, offset
+}
+
+func parseStockBound(value, name string, arrErrors *[]string) *int {
+	if value == "" {
+		return nil
+	}
+	stock, err := strconv.Atoi(value)
+	if err != nil || stock < 0 {
+		*arrErrors = append(*arrErrors, "Invalid "+name+", must be a number and not negative")
+		return nil
+	}
+	return &stock
+}
+
+func encodeCursor(cursor product.ProductCursor) string {
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(value string) (product.ProductCursor, error) {
+	var cursor product.ProductCursor
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	err = json.Unmarshal(raw, &cursor)
+	return cursor, err
+}
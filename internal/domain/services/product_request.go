@@ -0,0 +1,77 @@
+package services
+
+import (
+	product "CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// validationError carries the field messages collected while validating a request
+type validationError []string
+
+func (e validationError) Error() string {
+	return strings.Join(e, "; ")
+}
+
+// validateProductFields checks a complete set of editable product fields
+func validateProductFields(name *string, stock *int) []string {
+	var arrErrors []string
+
+	if name == nil || strings.TrimSpace(*name) == "" {
+		arrErrors = append(arrErrors, "Name cannot be empty")
+	}
+
+	if stock == nil || *stock < 0 {
+		arrErrors = append(arrErrors, "Invalid Stock Value, must be a number and greater than 0")
+	}
+
+	return arrErrors
+}
+
+// editableFields is the document a patch operates on; id and version are not patchable
+type editableFields struct {
+	Name  *string `json:"name"`
+	Stock *int    `json:"stock"`
+}
+
+func applyPatch(p *product.Product, req product.PatchProductRequest) error {
+	original, err := json.Marshal(editableFields{Name: &p.Name, Stock: &p.Stock})
+	if err != nil {
+		return err
+	}
+
+	var patched []byte
+	switch req.Format {
+	case product.MergePatch:
+		patched, err = jsonpatch.MergePatch(original, req.Document)
+	case product.JSONPatch:
+		var operations jsonpatch.Patch
+		operations, err = jsonpatch.DecodePatch(req.Document)
+		if err == nil {
+			patched, err = operations.Apply(original)
+		}
+	default:
+		return validationError{"Unsupported patch format " + string(req.Format)}
+	}
+	if err != nil {
+		return validationError{"Invalid patch: " + err.Error()}
+	}
+
+	var fields editableFields
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&fields); err != nil {
+		return validationError{"Invalid patch result: " + err.Error()}
+	}
+
+	if arrErrors := validateProductFields(fields.Name, fields.Stock); len(arrErrors) > 0 {
+		return validationError(arrErrors)
+	}
+
+	p.Name = *fields.Name
+	p.Stock = *fields.Stock
+	return nil
+}
@@ -2,43 +2,70 @@
 package services
 
 import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
 	product "CRUD-Go-Hexa-MongoDB/internal/domain/models"
 	"CRUD-Go-Hexa-MongoDB/internal/ports"
 	"CRUD-Go-Hexa-MongoDB/internal/utils"
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type ProductService struct {
-	productRepo ports.IProductRepository
+	productRepo  ports.IProductRepository
+	movementRepo ports.IStockMovementRepository
+	transactor   ports.ITransactor
+	// Products at or below this stock level match the low_stock filter
+	LowStockThreshold int
 }
 
-func NewProductService(productRepo ports.IProductRepository) *ProductService {
+func NewProductService(productRepo ports.IProductRepository, movementRepo ports.IStockMovementRepository, transactor ports.ITransactor) *ProductService {
 	return &ProductService{
-		productRepo: productRepo,
+		productRepo:       productRepo,
+		movementRepo:      movementRepo,
+		transactor:        transactor,
+		LowStockThreshold: DefaultLowStockThreshold,
 	}
 }
 
-func (s *ProductService) FindAll() utils.ServiceResponse {
-	products, err := s.productRepo.FindAll()
-	if err != nil {
+func (s *ProductService) FindAll(ctx context.Context, queryParams map[string]string) utils.ServiceResponse {
+	query, arrErrors := s.parseProductQuery(queryParams)
+	if len(arrErrors) > 0 {
 		return utils.ServiceResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to fetch products",
-			Data:    err.Error(),
+			Code:    http.StatusBadRequest,
+			Message: "Validation error",
+			Data:    arrErrors,
 		}
 	}
+
+	page, err := s.productRepo.FindAll(ctx, query)
+	if err != nil {
+		return errorResponse(err, "Failed to fetch products")
+	}
+
+	if page.Items == nil {
+		page.Items = []product.Product{}
+	}
+	page.Limit = query.Limit
+	page.Offset = query.Offset
+	if page.HasMore && len(page.Items) > 0 {
+		last := page.Items[len(page.Items)-1]
+		page.NextCursor = encodeCursor(product.CursorAfter(last, query.SortBy, query.Descending))
+	}
+
 	return utils.ServiceResponse{
 		Code:    http.StatusOK,
 		Message: "Products fetched successfully",
-		Data:    products,
+		Data:    page,
 	}
 }
 
-func (s *ProductService) FindByID(idStr string) utils.ServiceResponse {
+func (s *ProductService) FindByID(ctx context.Context, idStr string) utils.ServiceResponse {
 	id, err := uuid.Parse(idStr)
 	if err != nil {
 		return utils.ServiceResponse{
@@ -48,20 +75,16 @@ func (s *ProductService) FindByID(idStr string) utils.ServiceResponse {
 		}
 	}
 
-	product, err := s.productRepo.FindByID(id)
+	product, err := s.productRepo.FindByID(ctx, id)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		if errors.Is(err, errs.ErrNotFound) {
 			return utils.ServiceResponse{
 				Code:    http.StatusNotFound,
 				Message: "Product with ID " + idStr + " not found",
 				Data:    nil,
 			}
 		}
-		return utils.ServiceResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to fetch product",
-			Data:    err.Error(),
-		}
+		return errorResponse(err, "Failed to fetch product")
 	}
 	return utils.ServiceResponse{
 		Code:    http.StatusOK,
@@ -70,23 +93,9 @@ func (s *ProductService) FindByID(idStr string) utils.ServiceResponse {
 	}
 }
 
-func (s *ProductService) Create(productData map[string]string) utils.ServiceResponse {
-	name := productData["name"]
-	stockStr := productData["stock"]
-
+func (s *ProductService) Create(ctx context.Context, req product.CreateProductRequest) utils.ServiceResponse {
 	// Validate inputs
-	var arrErrors []string
-
-	if name == "" {
-		arrErrors = append(arrErrors, "Name cannot be empty")
-	}
-
-	stock, err := strconv.Atoi(stockStr)
-	if err != nil || stock < 0 {
-		arrErrors = append(arrErrors, "Invalid Stock Value, must be a number and greater than 0")
-	}
-
-	if len(arrErrors) > 0 {
+	if arrErrors := validateProductFields(req.Name, req.Stock); len(arrErrors) > 0 {
 		return utils.ServiceResponse{
 			Code:    http.StatusBadRequest,
 			Message: "Validation error",
@@ -96,18 +105,21 @@ func (s *ProductService) Create(productData map[string]string) utils.ServiceResp
 
 	// Create the product
 	product := product.Product{
-		ID:    uuid.New(),
-		Name:  name,
-		Stock: stock,
+		ID:        uuid.New(),
+		Name:      *req.Name,
+		Stock:     *req.Stock,
+		Version:   1,
+		UpdatedAt: time.Now().UTC(),
 	}
 
-	err = s.productRepo.Create(product)
-	if err != nil {
-		return utils.ServiceResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "Error creating product",
-			Data:    err.Error(),
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.productRepo.Create(ctx, product); err != nil {
+			return err
 		}
+		return s.recordMovement(ctx, product.ID, product.Stock, product.Stock, "product created", product.UpdatedAt)
+	})
+	if err != nil {
+		return errorResponse(err, "Error creating product")
 	}
 
 	return utils.ServiceResponse{
@@ -117,7 +129,8 @@ func (s *ProductService) Create(productData map[string]string) utils.ServiceResp
 	}
 }
 
-func (s *ProductService) Update(idStr string, productData map[string]string) utils.ServiceResponse {
+// Update replaces every editable field of the product, so all of them are required
+func (s *ProductService) Update(ctx context.Context, idStr string, req product.UpdateProductRequest, expected product.ExpectedVersions) utils.ServiceResponse {
 	// Parse the product ID. If invalid, treat it as "not found"
 	id, err := uuid.Parse(idStr)
 	if err != nil {
@@ -128,58 +141,112 @@ func (s *ProductService) Update(idStr string, productData map[string]string) uti
 		}
 	}
 
-	existingProduct, err := s.productRepo.FindByID(id)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return utils.ServiceResponse{
-				Code:    http.StatusNotFound,
-				Message: "Product with ID " + id.String() + " not found",
-				Data:    nil,
-			}
-		}
+	// Validate inputs
+	if arrErrors := validateProductFields(req.Name, req.Stock); len(arrErrors) > 0 {
 		return utils.ServiceResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to fetch product",
-			Data:    err.Error(),
+			Code:    http.StatusBadRequest,
+			Message: "Validation error",
+			Data:    arrErrors,
 		}
 	}
 
-	name := productData["name"]
-	stockStr := productData["stock"]
-
-	if name != "" {
-		existingProduct.Name = name
+	updatedProduct, err := s.modify(ctx, id, expected, func(p *product.Product) error {
+		p.Name = *req.Name
+		p.Stock = *req.Stock
+		return nil
+	})
+	if err != nil {
+		return modifyErrorResponse(err, id, "Error updating product")
 	}
 
-	if stockStr != "" {
-		stock, err := strconv.Atoi(stockStr)
-		if err != nil || stock < 0 {
-			return utils.ServiceResponse{
-				Code:    http.StatusBadRequest,
-				Message: "Invalid Stock Value, must be a number and greater than 0",
-				Data:    nil,
-			}
-		}
-		existingProduct.Stock = stock
+	return utils.ServiceResponse{
+		Code:    http.StatusOK,
+		Message: "Product updated successfully",
+		Data:    updatedProduct,
 	}
+}
 
-	err = s.productRepo.Update(existingProduct)
+// Patch applies a JSON Merge Patch or JSON Patch document to the product's editable fields
+func (s *ProductService) Patch(ctx context.Context, idStr string, req product.PatchProductRequest, expected product.ExpectedVersions) utils.ServiceResponse {
+	// Parse the product ID. If invalid, treat it as "not found"
+	id, err := uuid.Parse(idStr)
 	if err != nil {
 		return utils.ServiceResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "Error updating product",
-			Data:    err.Error(),
+			Code:    http.StatusNotFound,
+			Message: "Product with ID " + idStr + " not found",
+			Data:    nil,
 		}
 	}
 
+	patchedProduct, err := s.modify(ctx, id, expected, func(p *product.Product) error {
+		return applyPatch(p, req)
+	})
+	if err != nil {
+		return modifyErrorResponse(err, id, "Error patching product")
+	}
+
 	return utils.ServiceResponse{
 		Code:    http.StatusOK,
-		Message: "Product updated successfully",
-		Data:    existingProduct,
+		Message: "Product patched successfully",
+		Data:    patchedProduct,
 	}
 }
 
-func (s *ProductService) Delete(idStr string) utils.ServiceResponse {
+// modify runs a read-modify-write of one product in a transaction, honoring the expected
+// version and recording any stock change in the ledger
+func (s *ProductService) modify(ctx context.Context, id uuid.UUID, expected product.ExpectedVersions, apply func(p *product.Product) error) (product.Product, error) {
+	var updatedProduct product.Product
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		existingProduct, err := s.productRepo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if !expected.Allows(existingProduct.Version) {
+			return errs.PreconditionFailed("product %s has version %d", id, existingProduct.Version)
+		}
+
+		previousStock := existingProduct.Stock
+		if err := apply(&existingProduct); err != nil {
+			return err
+		}
+
+		existingProduct.UpdatedAt = time.Now().UTC()
+		updatedProduct, err = s.productRepo.Update(ctx, existingProduct)
+		if err != nil {
+			return err
+		}
+		return s.recordMovement(ctx, id, updatedProduct.Stock-previousStock, updatedProduct.Stock, "product updated", updatedProduct.UpdatedAt)
+	})
+	return updatedProduct, err
+}
+
+func modifyErrorResponse(err error, id uuid.UUID, message string) utils.ServiceResponse {
+	var invalid validationError
+	switch {
+	case errors.As(err, &invalid):
+		return utils.ServiceResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Validation error",
+			Data:    []string(invalid),
+		}
+	case errors.Is(err, errs.ErrNotFound):
+		return utils.ServiceResponse{
+			Code:    http.StatusNotFound,
+			Message: "Product with ID " + id.String() + " not found",
+			Data:    nil,
+		}
+	case errors.Is(err, errs.ErrPreconditionFailed):
+		return utils.ServiceResponse{
+			Code:    http.StatusPreconditionFailed,
+			Message: "Product with ID " + id.String() + " has been modified",
+			Data:    nil,
+		}
+	}
+	return errorResponse(err, message)
+}
+
+func (s *ProductService) Delete(ctx context.Context, idStr string, expected product.ExpectedVersions) utils.ServiceResponse {
 	// Parse the product ID. If invalid, treat it as "not found"
 	id, err := uuid.Parse(idStr)
 	if err != nil {
@@ -190,21 +257,42 @@ func (s *ProductService) Delete(idStr string) utils.ServiceResponse {
 		}
 	}
 
-	// Find and delete the product by ID
-	err = s.productRepo.Delete(id)
+	// Find and delete the product by ID, writing off its remaining stock
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		existingProduct, err := s.productRepo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if !expected.Allows(existingProduct.Version) {
+			return errs.PreconditionFailed("product %s has version %d", id, existingProduct.Version)
+		}
+
+		// Deleting the version that was read keeps the recorded balance accurate
+		if err := s.productRepo.Delete(ctx, id, existingProduct.Version); err != nil {
+			if len(expected) == 0 && errors.Is(err, errs.ErrPreconditionFailed) {
+				return errs.Conflict("product %s was modified concurrently", id)
+			}
+			return err
+		}
+		return s.recordMovement(ctx, id, -existingProduct.Stock, 0, "product deleted", time.Now().UTC())
+	})
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
+		switch {
+		case errors.Is(err, errs.ErrNotFound):
 			return utils.ServiceResponse{
 				Code:    http.StatusNotFound,
 				Message: "Product with ID " + idStr + " not found",
 				Data:    nil,
 			}
+		case errors.Is(err, errs.ErrPreconditionFailed):
+			return utils.ServiceResponse{
+				Code:    http.StatusPreconditionFailed,
+				Message: "Product with ID " + idStr + " has been modified",
+				Data:    nil,
+			}
 		}
-		return utils.ServiceResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "Error deleting product",
-			Data:    err.Error(),
-		}
+		return errorResponse(err, "Error deleting product")
 	}
 
 	// Return success response
@@ -214,3 +302,122 @@ func (s *ProductService) Delete(idStr string) utils.ServiceResponse {
 		Data:    nil,
 	}
 }
+
+// MaxReasonLength bounds the free-text reason recorded with a stock adjustment
+const MaxReasonLength = 255
+
+func (s *ProductService) AdjustStock(ctx context.Context, idStr string, req product.AdjustStockRequest) utils.ServiceResponse {
+	// Parse the product ID. If invalid, treat it as "not found"
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return utils.ServiceResponse{
+			Code:    http.StatusNotFound,
+			Message: "Product with ID " + idStr + " not found",
+			Data:    nil,
+		}
+	}
+
+	// Validate inputs
+	var arrErrors []string
+
+	if req.Delta == nil || *req.Delta == 0 {
+		arrErrors = append(arrErrors, "Invalid Delta Value, must be a non-zero number")
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		arrErrors = append(arrErrors, "Reason cannot be empty")
+	} else if len(reason) > MaxReasonLength {
+		arrErrors = append(arrErrors, "Reason cannot be longer than "+strconv.Itoa(MaxReasonLength)+" characters")
+	}
+
+	if len(arrErrors) > 0 {
+		return utils.ServiceResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Validation error",
+			Data:    arrErrors,
+		}
+	}
+
+	// The repository applies the delta atomically and refuses to go below zero
+	delta := *req.Delta
+	var adjustedProduct product.Product
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		adjustedProduct, err = s.productRepo.AdjustStock(ctx, id, delta, time.Now().UTC())
+		if err != nil {
+			return err
+		}
+		return s.recordMovement(ctx, id, delta, adjustedProduct.Stock, reason, adjustedProduct.UpdatedAt)
+	})
+	if err != nil {
+		switch errs.KindOf(err) {
+		case errs.KindNotFound:
+			return utils.ServiceResponse{
+				Code:    http.StatusNotFound,
+				Message: "Product with ID " + idStr + " not found",
+				Data:    nil,
+			}
+		case errs.KindConflict:
+			return utils.ServiceResponse{
+				Code:    http.StatusConflict,
+				Message: "Insufficient stock, adjustment would make stock negative",
+				Data:    nil,
+			}
+		}
+		return errorResponse(err, "Error adjusting stock")
+	}
+
+	return utils.ServiceResponse{
+		Code:    http.StatusOK,
+		Message: "Stock adjusted successfully",
+		Data:    adjustedProduct,
+	}
+}
+
+// recordMovement appends a ledger entry for a stock change; it must run in the transaction making the change
+func (s *ProductService) recordMovement(ctx context.Context, productID uuid.UUID, delta, balance int, reason string, at time.Time) error {
+	if delta == 0 {
+		return nil
+	}
+
+	return s.movementRepo.Append(ctx, product.StockMovement{
+		ID:        uuid.New(),
+		ProductID: productID,
+		Delta:     delta,
+		Balance:   balance,
+		Reason:    reason,
+		Actor:     utils.ActorFromContext(ctx),
+		CreatedAt: at,
+	})
+}
+
+// StatusClientClosedRequest answers requests the client abandoned. Nobody reads
+// it, but it keeps them apart from server errors in logs, metrics and profiling
+const StatusClientClosedRequest = 499
+
+// errorResponse maps a repository error onto the HTTP status matching its domain kind
+func errorResponse(err error, message string) utils.ServiceResponse {
+	code := http.StatusInternalServerError
+	switch errs.KindOf(err) {
+	case errs.KindNotFound:
+		code = http.StatusNotFound
+	case errs.KindConflict:
+		code = http.StatusConflict
+	case errs.KindValidation:
+		code = http.StatusBadRequest
+	case errs.KindUnavailable:
+		code = http.StatusServiceUnavailable
+	case errs.KindPreconditionFailed:
+		code = http.StatusPreconditionFailed
+	case errs.KindCanceled:
+		code = StatusClientClosedRequest
+	case errs.KindTimeout:
+		code = http.StatusGatewayTimeout
+	}
+
+	return utils.ServiceResponse{
+		Code:    code,
+		Message: message,
+		Data:    err.Error(),
+	}
+}
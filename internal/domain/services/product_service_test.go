@@ -1,14 +1,16 @@
 package services
 
 import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
 	product "CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"context"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // Mock Repository
@@ -16,35 +18,98 @@ type MockRepository struct {
 	mock.Mock
 }
 
-func (m *MockRepository) FindAll() ([]product.Product, error) {
-	args := m.Called()
-	return args.Get(0).([]product.Product), args.Error(1)
+func (m *MockRepository) FindAll(ctx context.Context, query product.ProductQuery) (product.ProductPage, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(product.ProductPage), args.Error(1)
 }
 
-func (m *MockRepository) FindByID(id uuid.UUID) (product.Product, error) {
-	args := m.Called(id)
+func (m *MockRepository) FindByID(ctx context.Context, id uuid.UUID) (product.Product, error) {
+	args := m.Called(ctx, id)
 	return args.Get(0).(product.Product), args.Error(1)
 }
 
-func (m *MockRepository) Create(product product.Product) error {
-	args := m.Called(product)
+func (m *MockRepository) Create(ctx context.Context, product product.Product) error {
+	args := m.Called(ctx, product)
 	return args.Error(0)
 }
 
-func (m *MockRepository) Update(product product.Product) error {
-	args := m.Called(product)
+func (m *MockRepository) Update(ctx context.Context, p product.Product) (product.Product, error) {
+	args := m.Called(ctx, p)
+	return args.Get(0).(product.Product), args.Error(1)
+}
+
+func (m *MockRepository) AdjustStock(ctx context.Context, id uuid.UUID, delta int, updatedAt time.Time) (product.Product, error) {
+	args := m.Called(ctx, id, delta, updatedAt)
+	return args.Get(0).(product.Product), args.Error(1)
+}
+
+func (m *MockRepository) Delete(ctx context.Context, id uuid.UUID, expectedVersion int64) error {
+	args := m.Called(ctx, id, expectedVersion)
 	return args.Error(0)
 }
 
-func (m *MockRepository) Delete(id uuid.UUID) error {
-	args := m.Called(id)
+// Mock movement ledger
+type MockMovementRepository struct {
+	mock.Mock
+}
+
+func (m *MockMovementRepository) Append(ctx context.Context, movement product.StockMovement) error {
+	args := m.Called(ctx, movement)
 	return args.Error(0)
 }
 
+func (m *MockMovementRepository) List(ctx context.Context, query product.MovementQuery) (product.MovementPage, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(product.MovementPage), args.Error(1)
+}
+
+func (m *MockMovementRepository) BalanceAt(ctx context.Context, productID uuid.UUID, at time.Time) (int, error) {
+	args := m.Called(ctx, productID, at)
+	return args.Int(0), args.Error(1)
+}
+
+// passthroughTransactor runs the callback without a real transaction
+type passthroughTransactor struct{}
+
+func (passthroughTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type txMarker struct{}
+
+// markingTransactor marks the context it hands to the callback, so tests can check
+// which calls ran inside the transaction
+type markingTransactor struct{}
+
+func (markingTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(context.WithValue(ctx, txMarker{}, true))
+}
+
+var inTransaction = mock.MatchedBy(func(ctx context.Context) bool {
+	return ctx.Value(txMarker{}) == true
+})
+
+func strPtr(value string) *string {
+	return &value
+}
+
+func intPtr(value int) *int {
+	return &value
+}
+
+// movementOf matches a ledger entry by product, delta, balance and reason
+func movementOf(productID uuid.UUID, delta, balance int, reason string) interface{} {
+	return mock.MatchedBy(func(m product.StockMovement) bool {
+		return m.ProductID == productID && m.Delta == delta && m.Balance == balance && m.Reason == reason
+	})
+}
+
 func TestFindAll(t *testing.T) {
 	// Setup
 	mockRepo := new(MockRepository)
-	productService := NewProductService(mockRepo)
+	mockMovementRepo := new(MockMovementRepository)
+	productService := NewProductService(mockRepo, mockMovementRepo, passthroughTransactor{})
+	ctx := context.Background()
 
 	t.Run("returns all products", func(t *testing.T) {
 		mockProducts := []product.Product{
@@ -60,32 +125,102 @@ func TestFindAll(t *testing.T) {
 			},
 		}
 
-		mockRepo.On("FindAll").Return(mockProducts, nil)
+		defaultQuery := product.ProductQuery{Limit: DefaultPageSize, SortBy: product.SortByName}
+		mockRepo.On("FindAll", ctx, defaultQuery).Return(product.ProductPage{Items: mockProducts, Total: 2}, nil)
 
-		response := productService.FindAll()
+		response := productService.FindAll(ctx, map[string]string{})
 		assert.Equal(t, http.StatusOK, response.Code)
 		assert.Equal(t, "Products fetched successfully", response.Message)
-		assert.Equal(t, mockProducts, response.Data)
+		page, ok := response.Data.(product.ProductPage)
+		assert.True(t, ok)
+		assert.Equal(t, mockProducts, page.Items)
+		assert.Equal(t, int64(2), page.Total)
+		assert.Equal(t, DefaultPageSize, page.Limit)
+		assert.Empty(t, page.NextCursor)
 		mockRepo.AssertExpectations(t)
 		mockRepo.ExpectedCalls = nil //reset expectations after each test
 	})
 
 	t.Run("returns empty list when no products found", func(t *testing.T) {
-		mockRepo.On("FindAll").Return([]product.Product{}, nil)
+		mockRepo.On("FindAll", ctx, mock.Anything).Return(product.ProductPage{}, nil)
 
-		response := productService.FindAll()
+		response := productService.FindAll(ctx, map[string]string{})
 		assert.Equal(t, http.StatusOK, response.Code)
 		assert.Equal(t, "Products fetched successfully", response.Message)
-		assert.Empty(t, response.Data)
+		assert.Empty(t, response.Data.(product.ProductPage).Items)
+		mockRepo.AssertExpectations(t)
+		mockRepo.ExpectedCalls = nil //reset expectations after each test
+	})
+
+	t.Run("passes filters and sort to the repository", func(t *testing.T) {
+		minStock, maxStock := 5, DefaultLowStockThreshold
+		expectedQuery := product.ProductQuery{
+			Limit:        10,
+			Offset:       20,
+			NameContains: "wid",
+			MinStock:     &minStock,
+			MaxStock:     &maxStock,
+			SortBy:       product.SortByStock,
+			Descending:   true,
+		}
+		mockRepo.On("FindAll", ctx, expectedQuery).Return(product.ProductPage{}, nil)
+
+		response := productService.FindAll(ctx, map[string]string{
+			"limit":     "10",
+			"offset":    "20",
+			"name":      "wid",
+			"min_stock": "5",
+			"max_stock": "50",
+			"low_stock": "true",
+			"sort":      "-stock",
+		})
+		assert.Equal(t, http.StatusOK, response.Code)
 		mockRepo.AssertExpectations(t)
 		mockRepo.ExpectedCalls = nil //reset expectations after each test
 	})
+
+	t.Run("returns a cursor that resumes after the last item", func(t *testing.T) {
+		last := product.Product{ID: uuid.New(), Name: "Product 2", Stock: 20}
+		mockRepo.On("FindAll", ctx, mock.Anything).Return(product.ProductPage{
+			Items:   []product.Product{{ID: uuid.New(), Name: "Product 1"}, last},
+			Total:   3,
+			HasMore: true,
+		}, nil).Once()
+
+		response := productService.FindAll(ctx, map[string]string{"limit": "2"})
+		page := response.Data.(product.ProductPage)
+		assert.NotEmpty(t, page.NextCursor)
+
+		expectedCursor := product.CursorAfter(last, product.SortByName, false)
+		mockRepo.On("FindAll", ctx, mock.MatchedBy(func(q product.ProductQuery) bool {
+			return q.After != nil && *q.After == expectedCursor
+		})).Return(product.ProductPage{}, nil).Once()
+
+		response = productService.FindAll(ctx, map[string]string{"limit": "2", "cursor": page.NextCursor})
+		assert.Equal(t, http.StatusOK, response.Code)
+		mockRepo.AssertExpectations(t)
+		mockRepo.ExpectedCalls = nil //reset expectations after each test
+	})
+
+	t.Run("returns validation error for invalid parameters", func(t *testing.T) {
+		response := productService.FindAll(ctx, map[string]string{
+			"limit":  "0",
+			"sort":   "price",
+			"cursor": "not-a-cursor",
+		})
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, "Validation error", response.Message)
+		assert.Len(t, response.Data, 3)
+		mockRepo.AssertExpectations(t)
+	})
 }
 
 func TestFindByID(t *testing.T) {
 	// Setup
 	mockRepo := new(MockRepository)
-	productService := NewProductService(mockRepo)
+	mockMovementRepo := new(MockMovementRepository)
+	productService := NewProductService(mockRepo, mockMovementRepo, passthroughTransactor{})
+	ctx := context.Background()
 
 	id := uuid.New()
 
@@ -96,9 +231,9 @@ func TestFindByID(t *testing.T) {
 			Stock: 10,
 		}
 
-		mockRepo.On("FindByID", id).Return(mockProduct, nil)
+		mockRepo.On("FindByID", ctx, id).Return(mockProduct, nil)
 
-		response := productService.FindByID(id.String())
+		response := productService.FindByID(ctx, id.String())
 		assert.Equal(t, http.StatusOK, response.Code)
 		assert.Equal(t, "Product fetched successfully", response.Message)
 		assert.Equal(t, mockProduct, response.Data)
@@ -107,21 +242,45 @@ func TestFindByID(t *testing.T) {
 	})
 
 	t.Run("returns not found error when product not found", func(t *testing.T) {
-		mockRepo.On("FindByID", id).Return(product.Product{}, mongo.ErrNoDocuments)
+		mockRepo.On("FindByID", ctx, id).Return(product.Product{}, errs.NotFound("product not found"))
 
-		response := productService.FindByID(id.String())
+		response := productService.FindByID(ctx, id.String())
 		assert.Equal(t, http.StatusNotFound, response.Code)
 		assert.Equal(t, "Product with ID "+id.String()+" not found", response.Message)
 		assert.Nil(t, response.Data)
 		mockRepo.AssertExpectations(t)
 		mockRepo.ExpectedCalls = nil //reset expectations after each test
 	})
+
+	t.Run("returns service unavailable when repository is unavailable", func(t *testing.T) {
+		mockRepo.On("FindByID", ctx, id).Return(product.Product{}, errs.Unavailable("connection refused"))
+
+		response := productService.FindByID(ctx, id.String())
+		assert.Equal(t, http.StatusServiceUnavailable, response.Code)
+		assert.Equal(t, "Failed to fetch product", response.Message)
+		mockRepo.AssertExpectations(t)
+		mockRepo.ExpectedCalls = nil //reset expectations after each test
+	})
+
+	t.Run("keeps aborted and timed out requests apart from server errors", func(t *testing.T) {
+		mockRepo.On("FindByID", ctx, id).Return(product.Product{}, errs.Wrap(errs.KindInternal, context.Canceled, "find product")).Once()
+		response := productService.FindByID(ctx, id.String())
+		assert.Equal(t, StatusClientClosedRequest, response.Code)
+
+		mockRepo.On("FindByID", ctx, id).Return(product.Product{}, context.DeadlineExceeded).Once()
+		response = productService.FindByID(ctx, id.String())
+		assert.Equal(t, http.StatusGatewayTimeout, response.Code)
+		mockRepo.AssertExpectations(t)
+		mockRepo.ExpectedCalls = nil //reset expectations after each test
+	})
 }
 
 func TestCreate(t *testing.T) {
 	// Setup
 	mockRepo := new(MockRepository)
-	productService := NewProductService(mockRepo)
+	mockMovementRepo := new(MockMovementRepository)
+	productService := NewProductService(mockRepo, mockMovementRepo, passthroughTransactor{})
+	ctx := context.Background()
 
 	t.Run("creates product successfully", func(t *testing.T) {
 		mockProduct := product.Product{
@@ -129,17 +288,22 @@ func TestCreate(t *testing.T) {
 			Stock: 10,
 		}
 
-		productData := map[string]string{
-			"name":  "Product 1",
-			"stock": "10",
+		productData := product.CreateProductRequest{
+			Name:  strPtr("Product 1"),
+			Stock: intPtr(10),
 		}
 
 		// Simulate repository behavior with the fixed UUID
-		mockRepo.On("Create", mock.MatchedBy(func(p product.Product) bool {
-			return p.Name == mockProduct.Name && p.Stock == mockProduct.Stock
+		mockRepo.On("Create", ctx, mock.MatchedBy(func(p product.Product) bool {
+			return p.Name == mockProduct.Name && p.Stock == mockProduct.Stock && p.Version == 1
 		})).Return(nil)
 
-		response := productService.Create(productData)
+		// The initial stock is recorded in the ledger
+		mockMovementRepo.On("Append", ctx, mock.MatchedBy(func(m product.StockMovement) bool {
+			return m.Delta == 10 && m.Balance == 10 && m.Reason == "product created" && m.Actor == "anonymous"
+		})).Return(nil)
+
+		response := productService.Create(ctx, productData)
 
 		assert.Equal(t, http.StatusCreated, response.Code)
 		assert.Equal(t, "Product created successfully", response.Message)
@@ -150,17 +314,35 @@ func TestCreate(t *testing.T) {
 		assert.Equal(t, mockProduct.Name, actualProduct.Name)
 		assert.Equal(t, mockProduct.Stock, actualProduct.Stock)
 
+		mockRepo.AssertExpectations(t)
+		mockMovementRepo.AssertExpectations(t)
+		mockRepo.ExpectedCalls = nil // reset expectations after each test
+		mockMovementRepo.ExpectedCalls = nil
+	})
+
+	t.Run("returns conflict when product already exists", func(t *testing.T) {
+		productData := product.CreateProductRequest{
+			Name:  strPtr("Product 1"),
+			Stock: intPtr(10),
+		}
+
+		mockRepo.On("Create", ctx, mock.Anything).Return(errs.Conflict("duplicate product"))
+
+		response := productService.Create(ctx, productData)
+
+		assert.Equal(t, http.StatusConflict, response.Code)
+		assert.Equal(t, "Error creating product", response.Message)
 		mockRepo.AssertExpectations(t)
 		mockRepo.ExpectedCalls = nil // reset expectations after each test
 	})
 
 	t.Run("returns validation error when name is empty", func(t *testing.T) {
-		productData := map[string]string{
-			"name":  "",
-			"stock": "10",
+		productData := product.CreateProductRequest{
+			Name:  strPtr(""),
+			Stock: intPtr(10),
 		}
 
-		response := productService.Create(productData)
+		response := productService.Create(ctx, productData)
 
 		assert.Equal(t, http.StatusBadRequest, response.Code)
 		assert.Equal(t, "Validation error", response.Message)
@@ -170,12 +352,12 @@ func TestCreate(t *testing.T) {
 	})
 
 	t.Run("returns validation error when stock is invalid", func(t *testing.T) {
-		productData := map[string]string{
-			"name":  "Product 1",
-			"stock": "-1",
+		productData := product.CreateProductRequest{
+			Name:  strPtr("Product 1"),
+			Stock: intPtr(-1),
 		}
 
-		response := productService.Create(productData)
+		response := productService.Create(ctx, productData)
 
 		assert.Equal(t, http.StatusBadRequest, response.Code)
 		assert.Equal(t, "Validation error", response.Message)
@@ -188,46 +370,58 @@ func TestCreate(t *testing.T) {
 func TestUpdate(t *testing.T) {
 	// Setup
 	mockRepo := new(MockRepository)
-	productService := NewProductService(mockRepo)
+	mockMovementRepo := new(MockMovementRepository)
+	productService := NewProductService(mockRepo, mockMovementRepo, passthroughTransactor{})
+	ctx := context.Background()
 
 	t.Run("updates product successfully", func(t *testing.T) {
 		id := uuid.New()
 		mockProduct := product.Product{
-			ID:    id,
-			Name:  "Product 1",
-			Stock: 10,
+			ID:      id,
+			Name:    "Product 1",
+			Stock:   10,
+			Version: 3,
 		}
+		updatedProduct := mockProduct
+		updatedProduct.Stock = 15
+		updatedProduct.Version = 4
 
 		// Mock FindByID to return product that needs updating
-		mockRepo.On("FindByID", id).Return(mockProduct, nil)
+		mockRepo.On("FindByID", ctx, id).Return(mockProduct, nil)
+
+		// Mock Update to confirm that it's called with the version that was read
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(p product.Product) bool {
+			return p.ID == id && p.Stock == 15 && p.Version == 3 && !p.UpdatedAt.IsZero()
+		})).Return(updatedProduct, nil)
 
-		// Mock Update to confirm that it's called using correct params
-		mockRepo.On("Update", mockProduct).Return(nil)
+		mockMovementRepo.On("Append", ctx, movementOf(id, 5, 15, "product updated")).Return(nil)
 
-		productData := map[string]string{
-			"name":  "Product 1",
-			"stock": "10",
+		productData := product.UpdateProductRequest{
+			Name:  strPtr("Product 1"),
+			Stock: intPtr(15),
 		}
 
-		response := productService.Update(id.String(), productData)
+		response := productService.Update(ctx, id.String(), productData, product.ExpectedVersions{3})
 		assert.Equal(t, http.StatusOK, response.Code)
 		assert.Equal(t, "Product updated successfully", response.Message)
-		assert.Equal(t, mockProduct, response.Data)
+		assert.Equal(t, updatedProduct, response.Data)
 		mockRepo.AssertExpectations(t)
+		mockMovementRepo.AssertExpectations(t)
 		mockRepo.ExpectedCalls = nil //reset expectations after each test
+		mockMovementRepo.ExpectedCalls = nil
 	})
 
 	t.Run("returns error when product not found", func(t *testing.T) {
 		nonExistentID := uuid.New()
 
-		productData := map[string]string{
-			"name":  "Product 1",
-			"stock": "10",
+		productData := product.UpdateProductRequest{
+			Name:  strPtr("Product 1"),
+			Stock: intPtr(10),
 		}
 
-		mockRepo.On("FindByID", nonExistentID).Return(product.Product{}, mongo.ErrNoDocuments)
+		mockRepo.On("FindByID", ctx, nonExistentID).Return(product.Product{}, errs.NotFound("product not found"))
 
-		response := productService.Update(nonExistentID.String(), productData)
+		response := productService.Update(ctx, nonExistentID.String(), productData, nil)
 		assert.Equal(t, http.StatusNotFound, response.Code)
 		assert.Equal(t, "Product with ID "+nonExistentID.String()+" not found", response.Message)
 		assert.Nil(t, response.Data)
@@ -237,17 +431,128 @@ func TestUpdate(t *testing.T) {
 
 	t.Run("returns error when stock is negative", func(t *testing.T) {
 		id := uuid.New()
-		productData := map[string]string{
-			"name":  "Product 1",
-			"stock": "-1",
+		productData := product.UpdateProductRequest{
+			Name:  strPtr("Product 1"),
+			Stock: intPtr(-1),
 		}
 
-		mockRepo.On("FindByID", id).Return(product.Product{}, nil)
+		response := productService.Update(ctx, id.String(), productData, nil)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, "Validation error", response.Message)
+		assert.Contains(t, response.Data, "Invalid Stock Value, must be a number and greater than 0")
+		mockRepo.AssertExpectations(t)
+		mockRepo.ExpectedCalls = nil //reset expectations after each test
+	})
 
-		response := productService.Update(id.String(), productData)
+	t.Run("requires every field because PUT replaces the product", func(t *testing.T) {
+		response := productService.Update(ctx, uuid.New().String(), product.UpdateProductRequest{Stock: intPtr(3)}, nil)
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, "Validation error", response.Message)
+		assert.Contains(t, response.Data, "Name cannot be empty")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("returns precondition failed when If-Match version is stale", func(t *testing.T) {
+		id := uuid.New()
+		mockRepo.On("FindByID", ctx, id).Return(product.Product{ID: id, Name: "Product 1", Version: 5}, nil)
+
+		response := productService.Update(ctx, id.String(), product.UpdateProductRequest{Name: strPtr("Product 1"), Stock: intPtr(1)}, product.ExpectedVersions{4})
+		assert.Equal(t, http.StatusPreconditionFailed, response.Code)
+		mockRepo.AssertExpectations(t)
+		mockRepo.ExpectedCalls = nil //reset expectations after each test
+	})
+
+	t.Run("accepts any version listed in If-Match", func(t *testing.T) {
+		id := uuid.New()
+		mockRepo.On("FindByID", ctx, id).Return(product.Product{ID: id, Name: "Product 1", Stock: 1, Version: 5}, nil)
+		mockRepo.On("Update", ctx, mock.Anything).Return(product.Product{ID: id, Name: "Product 2", Stock: 1, Version: 6}, nil)
+
+		response := productService.Update(ctx, id.String(), product.UpdateProductRequest{Name: strPtr("Product 2"), Stock: intPtr(1)}, product.ExpectedVersions{4, 5})
+		assert.Equal(t, http.StatusOK, response.Code)
+		mockRepo.AssertExpectations(t)
+		mockRepo.ExpectedCalls = nil //reset expectations after each test
+	})
+
+	t.Run("returns conflict when a concurrent update wins", func(t *testing.T) {
+		id := uuid.New()
+		mockRepo.On("FindByID", ctx, id).Return(product.Product{ID: id, Name: "Product 1", Version: 5}, nil)
+		mockRepo.On("Update", ctx, mock.Anything).Return(product.Product{}, errs.Conflict("product modified concurrently"))
+
+		response := productService.Update(ctx, id.String(), product.UpdateProductRequest{Name: strPtr("Product 1"), Stock: intPtr(1)}, product.ExpectedVersions{5})
+		assert.Equal(t, http.StatusConflict, response.Code)
+		assert.Equal(t, "Error updating product", response.Message)
+		mockRepo.AssertExpectations(t)
+		mockRepo.ExpectedCalls = nil //reset expectations after each test
+	})
+}
+
+func TestPatch(t *testing.T) {
+	// Setup
+	mockRepo := new(MockRepository)
+	mockMovementRepo := new(MockMovementRepository)
+	productService := NewProductService(mockRepo, mockMovementRepo, passthroughTransactor{})
+	ctx := context.Background()
+
+	id := uuid.New()
+	existingProduct := product.Product{ID: id, Name: "Product 1", Stock: 10, Version: 2}
+
+	t.Run("applies a merge patch to the given fields only", func(t *testing.T) {
+		mockRepo.On("FindByID", ctx, id).Return(existingProduct, nil)
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(p product.Product) bool {
+			return p.Name == "Renamed" && p.Stock == 10 && p.Version == 2
+		})).Return(product.Product{ID: id, Name: "Renamed", Stock: 10, Version: 3}, nil)
+
+		response := productService.Patch(ctx, id.String(), product.PatchProductRequest{
+			Format:   product.MergePatch,
+			Document: []byte(`{"name": "Renamed"}`),
+		}, nil)
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "Product patched successfully", response.Message)
+		mockRepo.AssertExpectations(t)
+		mockRepo.ExpectedCalls = nil //reset expectations after each test
+	})
+
+	t.Run("applies json patch operations and records the stock change", func(t *testing.T) {
+		mockRepo.On("FindByID", ctx, id).Return(existingProduct, nil)
+		mockRepo.On("Update", ctx, mock.MatchedBy(func(p product.Product) bool {
+			return p.Name == "Product 1" && p.Stock == 4
+		})).Return(product.Product{ID: id, Name: "Product 1", Stock: 4, Version: 3}, nil)
+		mockMovementRepo.On("Append", ctx, movementOf(id, -6, 4, "product updated")).Return(nil)
+
+		response := productService.Patch(ctx, id.String(), product.PatchProductRequest{
+			Format:   product.JSONPatch,
+			Document: []byte(`[{"op": "test", "path": "/stock", "value": 10}, {"op": "replace", "path": "/stock", "value": 4}]`),
+		}, product.ExpectedVersions{2})
+		assert.Equal(t, http.StatusOK, response.Code)
+		mockRepo.AssertExpectations(t)
+		mockMovementRepo.AssertExpectations(t)
+		mockRepo.ExpectedCalls = nil //reset expectations after each test
+		mockMovementRepo.ExpectedCalls = nil
+	})
+
+	t.Run("rejects patches that leave the product invalid", func(t *testing.T) {
+		mockRepo.On("FindByID", ctx, id).Return(existingProduct, nil)
+
+		for _, document := range []string{`{"name": null}`, `{"stock": -1}`, `{"version": 9}`} {
+			response := productService.Patch(ctx, id.String(), product.PatchProductRequest{
+				Format:   product.MergePatch,
+				Document: []byte(document),
+			}, nil)
+			assert.Equal(t, http.StatusBadRequest, response.Code, document)
+			assert.Equal(t, "Validation error", response.Message, document)
+		}
+		mockRepo.AssertExpectations(t)
+		mockRepo.ExpectedCalls = nil //reset expectations after each test
+	})
+
+	t.Run("rejects json patch whose test operation fails", func(t *testing.T) {
+		mockRepo.On("FindByID", ctx, id).Return(existingProduct, nil)
+
+		response := productService.Patch(ctx, id.String(), product.PatchProductRequest{
+			Format:   product.JSONPatch,
+			Document: []byte(`[{"op": "test", "path": "/stock", "value": 99}]`),
+		}, nil)
 		assert.Equal(t, http.StatusBadRequest, response.Code)
-		assert.Equal(t, "Invalid Stock Value, must be a number and greater than 0", response.Message)
-		assert.Nil(t, response.Data)
 		mockRepo.AssertExpectations(t)
 		mockRepo.ExpectedCalls = nil //reset expectations after each test
 	})
@@ -256,30 +561,173 @@ func TestUpdate(t *testing.T) {
 func TestDelete(t *testing.T) {
 	// Setup
 	mockRepo := new(MockRepository)
-	productService := NewProductService(mockRepo)
+	mockMovementRepo := new(MockMovementRepository)
+	productService := NewProductService(mockRepo, mockMovementRepo, passthroughTransactor{})
+	ctx := context.Background()
 
 	t.Run("deletes product successfully", func(t *testing.T) {
 		id := uuid.New()
 
-		mockRepo.On("Delete", id).Return(nil)
+		mockRepo.On("FindByID", ctx, id).Return(product.Product{ID: id, Stock: 4, Version: 2}, nil)
+		mockRepo.On("Delete", ctx, id, int64(2)).Return(nil)
+
+		// The remaining stock is written off in the ledger
+		mockMovementRepo.On("Append", ctx, movementOf(id, -4, 0, "product deleted")).Return(nil)
 
-		response := productService.Delete(id.String())
+		response := productService.Delete(ctx, id.String(), nil)
 		assert.Equal(t, http.StatusOK, response.Code)
 		assert.Equal(t, "Product deleted successfully", response.Message)
 		assert.Nil(t, response.Data)
 		mockRepo.AssertExpectations(t)
+		mockMovementRepo.AssertExpectations(t)
 		mockRepo.ExpectedCalls = nil //reset expectations after each test
+		mockMovementRepo.ExpectedCalls = nil
 	})
 
 	t.Run("returns error when product not found", func(t *testing.T) {
 		nonExistentID := uuid.New()
-		mockRepo.On("Delete", nonExistentID).Return(mongo.ErrNoDocuments)
+		mockRepo.On("FindByID", ctx, nonExistentID).Return(product.Product{}, errs.NotFound("product not found"))
 
-		response := productService.Delete(nonExistentID.String())
+		response := productService.Delete(ctx, nonExistentID.String(), nil)
 		assert.Equal(t, http.StatusNotFound, response.Code)
 		assert.Equal(t, "Product with ID "+nonExistentID.String()+" not found", response.Message)
 		assert.Nil(t, response.Data)
 		mockRepo.AssertExpectations(t)
 		mockRepo.ExpectedCalls = nil //reset expectations after each test
 	})
+
+	t.Run("returns precondition failed when version does not match", func(t *testing.T) {
+		id := uuid.New()
+		mockRepo.On("FindByID", ctx, id).Return(product.Product{ID: id, Stock: 4, Version: 3}, nil)
+
+		response := productService.Delete(ctx, id.String(), product.ExpectedVersions{2})
+		assert.Equal(t, http.StatusPreconditionFailed, response.Code)
+		mockRepo.AssertExpectations(t)
+		mockRepo.ExpectedCalls = nil //reset expectations after each test
+	})
+
+	t.Run("returns conflict when the product changes before it is deleted", func(t *testing.T) {
+		id := uuid.New()
+		mockRepo.On("FindByID", ctx, id).Return(product.Product{ID: id, Stock: 4, Version: 3}, nil)
+		mockRepo.On("Delete", ctx, id, int64(3)).Return(errs.PreconditionFailed("version mismatch"))
+
+		response := productService.Delete(ctx, id.String(), nil)
+		assert.Equal(t, http.StatusConflict, response.Code)
+		assert.Equal(t, "Error deleting product", response.Message)
+		mockRepo.AssertExpectations(t)
+		mockRepo.ExpectedCalls = nil //reset expectations after each test
+	})
+}
+
+func TestAdjustStock(t *testing.T) {
+	// Setup
+	mockRepo := new(MockRepository)
+	mockMovementRepo := new(MockMovementRepository)
+	productService := NewProductService(mockRepo, mockMovementRepo, passthroughTransactor{})
+	ctx := context.Background()
+
+	t.Run("adjusts stock successfully", func(t *testing.T) {
+		id := uuid.New()
+		adjustedProduct := product.Product{ID: id, Name: "Product 1", Stock: 7, Version: 2}
+
+		mockRepo.On("AdjustStock", ctx, id, -3, mock.AnythingOfType("time.Time")).Return(adjustedProduct, nil)
+		mockMovementRepo.On("Append", ctx, movementOf(id, -3, 7, "shipment")).Return(nil)
+
+		response := productService.AdjustStock(ctx, id.String(), product.AdjustStockRequest{Delta: intPtr(-3), Reason: "shipment"})
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "Stock adjusted successfully", response.Message)
+		assert.Equal(t, adjustedProduct, response.Data)
+		mockRepo.AssertExpectations(t)
+		mockMovementRepo.AssertExpectations(t)
+		mockRepo.ExpectedCalls = nil //reset expectations after each test
+		mockMovementRepo.ExpectedCalls = nil
+	})
+
+	t.Run("rejects adjustment that would make stock negative", func(t *testing.T) {
+		id := uuid.New()
+
+		mockRepo.On("AdjustStock", ctx, id, -30, mock.AnythingOfType("time.Time")).Return(product.Product{}, errs.Conflict("stock would be negative"))
+
+		response := productService.AdjustStock(ctx, id.String(), product.AdjustStockRequest{Delta: intPtr(-30), Reason: "shipment"})
+		assert.Equal(t, http.StatusConflict, response.Code)
+		assert.Equal(t, "Insufficient stock, adjustment would make stock negative", response.Message)
+		mockRepo.AssertExpectations(t)
+		mockRepo.ExpectedCalls = nil //reset expectations after each test
+	})
+
+	t.Run("returns validation error for zero delta and missing reason", func(t *testing.T) {
+		response := productService.AdjustStock(ctx, uuid.New().String(), product.AdjustStockRequest{Delta: intPtr(0)})
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Equal(t, "Validation error", response.Message)
+		assert.Contains(t, response.Data, "Invalid Delta Value, must be a non-zero number")
+		assert.Contains(t, response.Data, "Reason cannot be empty")
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestListMovements(t *testing.T) {
+	// Setup
+	mockRepo := new(MockRepository)
+	mockMovementRepo := new(MockMovementRepository)
+	productService := NewProductService(mockRepo, mockMovementRepo, markingTransactor{})
+	ctx := context.Background()
+
+	t.Run("returns movements and the balance at the end of the range", func(t *testing.T) {
+		id := uuid.New()
+		to := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		movements := []product.StockMovement{
+			{ID: uuid.New(), ProductID: id, Delta: -2, Balance: 8, Reason: "shipment"},
+			{ID: uuid.New(), ProductID: id, Delta: 10, Balance: 10, Reason: "product created"},
+		}
+
+		// The newest movement of the first page carries the balance
+		mockMovementRepo.On("List", inTransaction, product.MovementQuery{ProductID: id, Limit: 5, To: &to}).
+			Return(product.MovementPage{Items: movements, Total: 2}, nil)
+
+		response := productService.ListMovements(ctx, id.String(), map[string]string{
+			"limit": "5",
+			"to":    "2024-05-01T12:00:00Z",
+		})
+		assert.Equal(t, http.StatusOK, response.Code)
+		assert.Equal(t, "Stock movements fetched successfully", response.Message)
+		page := response.Data.(product.MovementPage)
+		assert.Equal(t, movements, page.Items)
+		assert.Equal(t, 8, page.Balance)
+		assert.Equal(t, to, page.BalanceAt)
+		mockMovementRepo.AssertExpectations(t)
+		mockMovementRepo.ExpectedCalls = nil //reset expectations after each test
+	})
+
+	t.Run("reads the balance in the same transaction on later pages", func(t *testing.T) {
+		id := uuid.New()
+		to := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		movements := []product.StockMovement{
+			{ID: uuid.New(), ProductID: id, Delta: 10, Balance: 10, Reason: "product created"},
+		}
+
+		mockMovementRepo.On("List", inTransaction, product.MovementQuery{ProductID: id, Limit: 1, Offset: 1, To: &to}).
+			Return(product.MovementPage{Items: movements, Total: 2}, nil)
+		mockMovementRepo.On("BalanceAt", inTransaction, id, to).Return(8, nil)
+
+		response := productService.ListMovements(ctx, id.String(), map[string]string{
+			"limit":  "1",
+			"offset": "1",
+			"to":     "2024-05-01T12:00:00Z",
+		})
+		assert.Equal(t, http.StatusOK, response.Code)
+		page := response.Data.(product.MovementPage)
+		assert.Equal(t, 8, page.Balance)
+		mockMovementRepo.AssertExpectations(t)
+		mockMovementRepo.ExpectedCalls = nil //reset expectations after each test
+	})
+
+	t.Run("returns validation error for invalid range", func(t *testing.T) {
+		response := productService.ListMovements(ctx, uuid.New().String(), map[string]string{
+			"from": "2024-05-02T00:00:00Z",
+			"to":   "yesterday",
+		})
+		assert.Equal(t, http.StatusBadRequest, response.Code)
+		assert.Contains(t, response.Data, "Invalid to, must be an RFC 3339 timestamp")
+		mockMovementRepo.AssertExpectations(t)
+	})
 }
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"fmt"
+	"path"
+	"time"
+)
+
+// SamplingRules decide which profiling records are worth writing. Exclude and Include
+// filter by route first; of the remaining records, errors and slow requests can be kept
+// unconditionally and the rest are kept at Rate
+type SamplingRules struct {
+	// Rate is the fraction of ordinary records kept, from 0 to 1. Those kept are
+	// weighted 1/Rate so that summaries still estimate every request
+	Rate float64
+	// SlowThreshold keeps every request that took at least this long, zero disables it
+	SlowThreshold time.Duration
+	// KeepErrors keeps every request that failed on the server side
+	KeepErrors bool
+	// Include, when not empty, limits profiling to matching routes. Patterns use path.Match
+	// syntax and match either the route ("/products/*") or method and route ("GET /products/:id")
+	Include []string
+	// Exclude drops matching routes, even slow or failed ones
+	Exclude []string
+}
+
+// Validate reports a rate out of range or a malformed pattern
+func (r SamplingRules) Validate() error {
+	if r.Rate < 0 || r.Rate > 1 {
+		return fmt.Errorf("sampling rate must be between 0 and 1, got %v", r.Rate)
+	}
+	if r.SlowThreshold < 0 {
+		return fmt.Errorf("slow threshold must not be negative, got %v", r.SlowThreshold)
+	}
+	for _, pattern := range append(append([]string(nil), r.Include...), r.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid route pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// keep decides whether a record is written, and with what weight: records kept by
+// chance stand for the 1/Rate requests like them. random returns a number in [0, 1)
+func (r SamplingRules) keep(profiling models.Profiling, random func() float64) (float64, bool) {
+	if matchesAny(r.Exclude, profiling) {
+		return 0, false
+	}
+	if len(r.Include) > 0 && !matchesAny(r.Include, profiling) {
+		return 0, false
+	}
+	if r.KeepErrors && profiling.IsError() {
+		return 1, true
+	}
+	if r.SlowThreshold > 0 && profiling.Duration >= r.SlowThreshold.Microseconds() {
+		return 1, true
+	}
+	if random() < r.Rate {
+		return 1 / r.Rate, true
+	}
+	return 0, false
+}
+
+func matchesAny(patterns []string, profiling models.Profiling) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, profiling.Route); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, profiling.APICall); ok {
+			return true
+		}
+	}
+	return false
+}
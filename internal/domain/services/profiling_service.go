@@ -1,22 +1,227 @@
 package services
 
 import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
 	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
 	"CRUD-Go-Hexa-MongoDB/internal/ports"
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// OverflowPolicy decides what Log does when the profiling queue is full
+type OverflowPolicy string
+
+const (
+	// OverflowDrop discards the record so requests never wait on profiling
+	OverflowDrop OverflowPolicy = "drop"
+	// OverflowBlock makes Log wait for room, slowing requests down to the store's pace
+	OverflowBlock OverflowPolicy = "block"
+)
+
+// Profiling pipeline defaults, used for zero options
+const (
+	DefaultProfilingQueueSize     = 1024
+	DefaultProfilingBatchSize     = 100
+	DefaultProfilingFlushInterval = time.Second
+	// MaxProfilingBatchSize keeps a batch insert within the SQL adapters' parameter limits
+	MaxProfilingBatchSize = 1000
+	// profilingWriteTimeout bounds a single batch insert
+	profilingWriteTimeout = 5 * time.Second
+)
+
+type ProfilingOptions struct {
+	// QueueSize is how many records may wait to be written
+	QueueSize int
+	// BatchSize is how many records are written at once; a full batch is written right away
+	BatchSize int
+	// FlushInterval is the longest a partial batch waits before being written
+	FlushInterval time.Duration
+	Overflow      OverflowPolicy
+	// Sampling filters records before they are queued, nil keeps every record
+	Sampling *SamplingRules
+	// Logger reports failed writes, nil uses slog.Default
+	Logger *slog.Logger
+}
+
+func (o ProfilingOptions) withDefaults() ProfilingOptions {
+	if o.QueueSize <= 0 {
+		o.QueueSize = DefaultProfilingQueueSize
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultProfilingBatchSize
+	}
+	if o.BatchSize > MaxProfilingBatchSize {
+		o.BatchSize = MaxProfilingBatchSize
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = DefaultProfilingFlushInterval
+	}
+	if o.Overflow != OverflowBlock {
+		o.Overflow = OverflowDrop
+	}
+	if o.Logger == nil {
+		o.Logger = slog.Default()
+	}
+	return o
+}
+
+// ProfilingService queues records and writes them in batches from a background
+// goroutine, so requests never wait on the profiling store
 type ProfilingService struct {
 	profilingRepo ports.IProfilingRepository
+	options       ProfilingOptions
+	queue         chan models.Profiling
+	done          chan struct{}
+	random        func() float64
+
+	// mu guards closed; Log holds it for reading while sending so Flush cannot close the queue under it
+	mu     sync.RWMutex
+	closed bool
+
+	written atomic.Uint64
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+	skipped atomic.Uint64
+	// failing is set from a failed write until the next successful one, so an outage
+	// is logged once rather than once per batch
+	failing atomic.Bool
 }
 
-func NewProfilingService(profilingRepo ports.IProfilingRepository) *ProfilingService {
-	return &ProfilingService{
+func NewProfilingService(profilingRepo ports.IProfilingRepository, options ProfilingOptions) *ProfilingService {
+	options = options.withDefaults()
+	s := &ProfilingService{
 		profilingRepo: profilingRepo,
+		options:       options,
+		queue:         make(chan models.Profiling, options.QueueSize),
+		done:          make(chan struct{}),
+		random:        rand.Float64,
 	}
+	go s.run()
+	return s
 }
 
-func (s *ProfilingService) Log(profiling models.Profiling) error {
+// Log queues a record unless the sampling rules skip it. Under OverflowBlock it waits
+// for room until ctx is done
+func (s *ProfilingService) Log(ctx context.Context, profiling models.Profiling) error {
+	if s.options.Sampling != nil {
+		weight, keep := s.options.Sampling.keep(profiling, s.random)
+		if !keep {
+			s.skipped.Add(1)
+			return nil
+		}
+		profiling.Weight = weight
+	}
 	profiling.Timestamp = time.Now()
-	return s.profilingRepo.Create(profiling)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		s.dropped.Add(1)
+		return nil
+	}
+
+	if s.options.Overflow == OverflowBlock {
+		select {
+		case s.queue <- profiling:
+			return nil
+		case <-ctx.Done():
+			s.dropped.Add(1)
+			return ctx.Err()
+		}
+	}
+
+	select {
+	case s.queue <- profiling:
+	default:
+		s.dropped.Add(1)
+	}
+	return nil
+}
+
+// Flush stops accepting records and waits until the queued ones are written or ctx is done
+func (s *ProfilingService) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *ProfilingService) Stats() models.ProfilingStats {
+	return models.ProfilingStats{
+		Queued:  len(s.queue),
+		Written: s.written.Load(),
+		Dropped: s.dropped.Load(),
+		Failed:  s.failed.Load(),
+		Skipped: s.skipped.Load(),
+	}
+}
+
+// run collects queued records into batches until the queue is closed and drained
+func (s *ProfilingService) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.options.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.Profiling, 0, s.options.BatchSize)
+	for {
+		select {
+		case profiling, ok := <-s.queue:
+			if !ok {
+				s.write(batch)
+				return
+			}
+			batch = append(batch, profiling)
+			if len(batch) >= s.options.BatchSize {
+				s.write(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			s.write(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (s *ProfilingService) write(batch []models.Profiling) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), profilingWriteTimeout)
+	defer cancel()
+
+	if err := s.profilingRepo.CreateMany(ctx, batch); err != nil {
+		failed := len(batch)
+		var partial *errs.PartialError
+		if errors.As(err, &partial) {
+			failed = partial.Failed
+		}
+		s.failed.Add(uint64(failed))
+		s.written.Add(uint64(len(batch) - failed))
+		level := slog.LevelDebug
+		if !s.failing.Swap(true) {
+			level = slog.LevelError
+		}
+		s.options.Logger.Log(ctx, level, "failed to write profiling records", "count", failed, "error", err)
+		return
+	}
+	s.written.Add(uint64(len(batch)))
+	if s.failing.Swap(false) {
+		s.options.Logger.Info("profiling writes recovered")
+	}
 }
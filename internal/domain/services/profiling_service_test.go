@@ -0,0 +1,285 @@
+package services
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/errs"
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchRecorder is a profiling repository that records the batches it receives
+// and the summary queries it is asked. Writes block while gate is set, and fail while err is set
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]models.Profiling
+	queries []models.ProfilingQuery
+	gate    chan struct{}
+	err     error
+}
+
+func (r *batchRecorder) CreateMany(ctx context.Context, records []models.Profiling) error {
+	if r.gate != nil {
+		<-r.gate
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.batches = append(r.batches, append([]models.Profiling(nil), records...))
+	return nil
+}
+
+func (r *batchRecorder) Summarize(ctx context.Context, query models.ProfilingQuery) ([]models.RouteStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries = append(r.queries, query)
+	return nil, r.err
+}
+
+func (r *batchRecorder) batchSizes() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sizes := make([]int, len(r.batches))
+	for i, batch := range r.batches {
+		sizes[i] = len(batch)
+	}
+	return sizes
+}
+
+func TestProfilingBatchesBySize(t *testing.T) {
+	repo := &batchRecorder{}
+	service := NewProfilingService(repo, ProfilingOptions{BatchSize: 3, FlushInterval: time.Hour})
+	ctx := context.Background()
+
+	for i := 0; i < 7; i++ {
+		require.NoError(t, service.Log(ctx, models.Profiling{APICall: "GET /products"}))
+	}
+	require.Eventually(t, func() bool { return len(repo.batchSizes()) == 2 }, time.Second, time.Millisecond)
+
+	// Flush writes the partial batch that was still waiting
+	require.NoError(t, service.Flush(ctx))
+	assert.Equal(t, []int{3, 3, 1}, repo.batchSizes())
+	assert.Equal(t, models.ProfilingStats{Written: 7}, service.Stats())
+	assert.False(t, repo.batches[0][0].Timestamp.IsZero())
+}
+
+func TestProfilingBatchesByInterval(t *testing.T) {
+	repo := &batchRecorder{}
+	service := NewProfilingService(repo, ProfilingOptions{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	defer service.Flush(context.Background())
+
+	require.NoError(t, service.Log(context.Background(), models.Profiling{}))
+	require.Eventually(t, func() bool { return len(repo.batchSizes()) == 1 }, time.Second, time.Millisecond)
+}
+
+func TestProfilingOverflow(t *testing.T) {
+	t.Run("drop", func(t *testing.T) {
+		repo := &batchRecorder{gate: make(chan struct{})}
+		service := NewProfilingService(repo, ProfilingOptions{QueueSize: 2, BatchSize: 1, Overflow: OverflowDrop})
+		ctx := context.Background()
+
+		// The first record is taken by the writer, which then blocks on the gate
+		require.NoError(t, service.Log(ctx, models.Profiling{}))
+		require.Eventually(t, func() bool { return service.Stats().Queued == 0 }, time.Second, time.Millisecond)
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, service.Log(ctx, models.Profiling{}))
+		}
+		assert.Equal(t, models.ProfilingStats{Queued: 2, Dropped: 3}, service.Stats())
+
+		close(repo.gate)
+		require.NoError(t, service.Flush(ctx))
+		assert.Equal(t, models.ProfilingStats{Written: 3, Dropped: 3}, service.Stats())
+
+		// Records logged after Flush are dropped
+		require.NoError(t, service.Log(ctx, models.Profiling{}))
+		assert.Equal(t, uint64(4), service.Stats().Dropped)
+	})
+
+	t.Run("block", func(t *testing.T) {
+		repo := &batchRecorder{gate: make(chan struct{})}
+		service := NewProfilingService(repo, ProfilingOptions{QueueSize: 1, BatchSize: 1, Overflow: OverflowBlock})
+
+		require.NoError(t, service.Log(context.Background(), models.Profiling{}))
+		require.Eventually(t, func() bool { return service.Stats().Queued == 0 }, time.Second, time.Millisecond)
+		require.NoError(t, service.Log(context.Background(), models.Profiling{}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := service.Log(ctx, models.Profiling{})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, uint64(1), service.Stats().Dropped)
+
+		close(repo.gate)
+		require.NoError(t, service.Flush(context.Background()))
+		assert.Equal(t, uint64(2), service.Stats().Written)
+	})
+}
+
+func TestProfilingCountsFailedWrites(t *testing.T) {
+	repo := &batchRecorder{err: errs.Unavailable("profiling store is down")}
+	service := NewProfilingService(repo, ProfilingOptions{BatchSize: 2})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, service.Log(ctx, models.Profiling{}))
+	}
+	require.NoError(t, service.Flush(ctx))
+	assert.Equal(t, models.ProfilingStats{Failed: 3}, service.Stats())
+}
+
+func TestProfilingCountsPartialWrites(t *testing.T) {
+	repo := &batchRecorder{err: errs.Partial(1, errs.Validation("one record rejected"))}
+	service := NewProfilingService(repo, ProfilingOptions{BatchSize: 3})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, service.Log(ctx, models.Profiling{}))
+	}
+	require.NoError(t, service.Flush(ctx))
+	assert.Equal(t, models.ProfilingStats{Written: 2, Failed: 1}, service.Stats())
+}
+
+func TestProfilingFlushHonorsDeadline(t *testing.T) {
+	repo := &batchRecorder{gate: make(chan struct{})}
+	defer close(repo.gate)
+	service := NewProfilingService(repo, ProfilingOptions{BatchSize: 1})
+
+	require.NoError(t, service.Log(context.Background(), models.Profiling{}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, service.Flush(ctx), context.DeadlineExceeded)
+}
+
+func TestProfilingSummarize(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("defaults to the last hour", func(t *testing.T) {
+		repo := &batchRecorder{}
+		service := NewProfilingService(repo, ProfilingOptions{})
+		defer service.Flush(ctx)
+
+		result := service.Summarize(ctx, map[string]string{"method": "get", "route": "/products/:id"})
+		require.Equal(t, 200, result.Code)
+		report := result.Data.(models.ProfilingReport)
+		assert.Equal(t, []models.RouteStats{}, report.Routes)
+		assert.Empty(t, report.Window)
+
+		require.Len(t, repo.queries, 1)
+		query := repo.queries[0]
+		assert.Equal(t, "GET", query.Method)
+		assert.Equal(t, "/products/:id", query.Route)
+		assert.Equal(t, time.Hour, query.To.Sub(query.From))
+		assert.Zero(t, query.Window)
+	})
+
+	t.Run("uses the given range and window", func(t *testing.T) {
+		repo := &batchRecorder{}
+		service := NewProfilingService(repo, ProfilingOptions{})
+		defer service.Flush(ctx)
+
+		result := service.Summarize(ctx, map[string]string{
+			"from":   "2024-01-01T00:00:00Z",
+			"to":     "2024-01-01T06:00:00Z",
+			"window": "1h",
+		})
+		require.Equal(t, 200, result.Code)
+		assert.Equal(t, "1h0m0s", result.Data.(models.ProfilingReport).Window)
+
+		require.Len(t, repo.queries, 1)
+		assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), repo.queries[0].From)
+		assert.Equal(t, time.Hour, repo.queries[0].Window)
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		repo := &batchRecorder{}
+		service := NewProfilingService(repo, ProfilingOptions{})
+		defer service.Flush(ctx)
+
+		result := service.Summarize(ctx, map[string]string{
+			"from":   "2024-01-02T00:00:00Z",
+			"to":     "2024-01-01T00:00:00Z",
+			"window": "10ms",
+		})
+		assert.Equal(t, 400, result.Code)
+		assert.Equal(t, []string{
+			"Invalid time range, from must be before to",
+			"Invalid window, must be a duration of at least 1s such as 5m or 1h",
+		}, result.Data)
+
+		result = service.Summarize(ctx, map[string]string{"from": "2024-01-01T00:00:00Z", "to": "2024-02-01T00:00:00Z", "window": "1s"})
+		assert.Equal(t, 400, result.Code)
+		assert.Empty(t, repo.queries)
+	})
+
+	t.Run("store failure", func(t *testing.T) {
+		repo := &batchRecorder{err: errs.Unavailable("profiling store is down")}
+		service := NewProfilingService(repo, ProfilingOptions{})
+		defer service.Flush(ctx)
+
+		result := service.Summarize(ctx, nil)
+		assert.Equal(t, 503, result.Code)
+	})
+}
+
+func TestSamplingRules(t *testing.T) {
+	record := func(apiCall, route string, status int, duration time.Duration) models.Profiling {
+		return models.Profiling{APICall: apiCall, Route: route, Status: status, Duration: duration.Microseconds()}
+	}
+	list := record("GET /products", "/products", 200, time.Millisecond)
+	slow := record("GET /products", "/products", 200, time.Second)
+	failed := record("GET /products", "/products", 500, time.Millisecond)
+	byID := record("GET /products/:id", "/products/:id", 200, time.Millisecond)
+	admin := record("GET /admin/profiling", "/admin/profiling", 500, time.Second)
+
+	rules := SamplingRules{
+		Rate:          0.25,
+		SlowThreshold: 500 * time.Millisecond,
+		KeepErrors:    true,
+		Exclude:       []string{"/admin/*"},
+	}
+	require.NoError(t, rules.Validate())
+
+	lucky := func() float64 { return 0.1 }
+	unlucky := func() float64 { return 0.9 }
+
+	kept := func(profiling models.Profiling, random func() float64) float64 {
+		weight, keep := rules.keep(profiling, random)
+		assert.Equal(t, keep, weight > 0)
+		return weight
+	}
+	// Ordinary records kept by chance stand for the ones sampled out
+	assert.Equal(t, 4.0, kept(list, lucky))
+	assert.Zero(t, kept(list, unlucky))
+	assert.Equal(t, 1.0, kept(slow, unlucky))
+	assert.Equal(t, 1.0, kept(failed, unlucky))
+	// Exclusion wins over the always-keep rules
+	assert.Zero(t, kept(admin, lucky))
+
+	rules.Include = []string{"GET /products/*"}
+	assert.Equal(t, 4.0, kept(byID, lucky))
+	assert.Zero(t, kept(failed, lucky))
+
+	assert.Error(t, SamplingRules{Rate: 1.5}.Validate())
+	assert.Error(t, SamplingRules{Rate: 1, Include: []string{"[/products"}}.Validate())
+}
+
+func TestProfilingSkipsSampledOutRecords(t *testing.T) {
+	repo := &batchRecorder{}
+	service := NewProfilingService(repo, ProfilingOptions{Sampling: &SamplingRules{Rate: 0, KeepErrors: true}})
+	ctx := context.Background()
+
+	require.NoError(t, service.Log(ctx, models.Profiling{Status: 200}))
+	require.NoError(t, service.Log(ctx, models.Profiling{Status: 503}))
+	require.NoError(t, service.Flush(ctx))
+
+	assert.Equal(t, models.ProfilingStats{Written: 1, Skipped: 1}, service.Stats())
+	assert.Equal(t, 503, repo.batches[0][0].Status)
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/utils"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultProfilingRange is how far back a summary looks when from is not given
+	DefaultProfilingRange = time.Hour
+	// MaxProfilingWindows bounds how many windows one summary may be split into
+	MaxProfilingWindows = 1000
+	// MinProfilingWindow is the shortest window a summary may use
+	MinProfilingWindow = time.Second
+)
+
+// Summarize reports per-route request counts, error rates and latency percentiles.
+// The range defaults to the last hour and is split into windows when window is given
+func (s *ProfilingService) Summarize(ctx context.Context, queryParams map[string]string) utils.ServiceResponse {
+	// Validate inputs
+	var arrErrors []string
+
+	query := models.ProfilingQuery{
+		Method: strings.ToUpper(strings.TrimSpace(queryParams["method"])),
+		Route:  strings.TrimSpace(queryParams["route"]),
+	}
+
+	query.To = time.Now().UTC()
+	if to := parseTime(queryParams["to"], "to", &arrErrors); to != nil {
+		query.To = to.UTC()
+	}
+	query.From = query.To.Add(-DefaultProfilingRange)
+	if from := parseTime(queryParams["from"], "from", &arrErrors); from != nil {
+		query.From = from.UTC()
+	}
+	if !query.From.Before(query.To) {
+		arrErrors = append(arrErrors, "Invalid time range, from must be before to")
+	}
+
+	if windowStr := queryParams["window"]; windowStr != "" {
+		window, err := time.ParseDuration(windowStr)
+		switch {
+		case err != nil || window < MinProfilingWindow:
+			arrErrors = append(arrErrors, "Invalid window, must be a duration of at least 1s such as 5m or 1h")
+		case query.To.Sub(query.From)/window >= MaxProfilingWindows:
+			arrErrors = append(arrErrors, "Invalid window, the range would be split into too many windows")
+		default:
+			query.Window = window
+		}
+	}
+
+	if len(arrErrors) > 0 {
+		return utils.ServiceResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Validation error",
+			Data:    arrErrors,
+		}
+	}
+
+	routes, err := s.profilingRepo.Summarize(ctx, query)
+	if err != nil {
+		return errorResponse(err, "Failed to summarize profiling records")
+	}
+	if routes == nil {
+		routes = []models.RouteStats{}
+	}
+
+	report := models.ProfilingReport{
+		From:     query.From,
+		To:       query.To,
+		Routes:   routes,
+		Pipeline: s.Stats(),
+	}
+	if query.Window > 0 {
+		report.Window = query.Window.String()
+	}
+
+	return utils.ServiceResponse{
+		Code:    http.StatusOK,
+		Message: "Profiling summary fetched successfully",
+		Data:    report,
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	product "CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"CRUD-Go-Hexa-MongoDB/internal/utils"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ListMovements pages through a product's stock ledger, newest first, and reconstructs
+// the stock as of the "to" bound (or now). History outlives the product, so deleted
+// products can still be queried
+func (s *ProductService) ListMovements(ctx context.Context, idStr string, queryParams map[string]string) utils.ServiceResponse {
+	// Parse the product ID. If invalid, treat it as "not found"
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return utils.ServiceResponse{
+			Code:    http.StatusNotFound,
+			Message: "Product with ID " + idStr + " not found",
+			Data:    nil,
+		}
+	}
+
+	// Validate inputs
+	var arrErrors []string
+
+	query := product.MovementQuery{ProductID: id}
+	query.Limit, query.Offset = parsePagination(queryParams, &arrErrors)
+	query.From = parseTime(queryParams["from"], "from", &arrErrors)
+	query.To = parseTime(queryParams["to"], "to", &arrErrors)
+	if query.From != nil && query.To != nil && query.From.After(*query.To) {
+		arrErrors = append(arrErrors, "Invalid time range, from must not be after to")
+	}
+
+	if len(arrErrors) > 0 {
+		return utils.ServiceResponse{
+			Code:    http.StatusBadRequest,
+			Message: "Validation error",
+			Data:    arrErrors,
+		}
+	}
+
+	balanceAt := time.Now().UTC()
+	if query.To != nil {
+		balanceAt = *query.To
+	}
+
+	// Both reads share a transaction so a concurrent adjustment cannot land between them
+	var page product.MovementPage
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		page, err = s.movementRepo.List(ctx, query)
+		if err != nil {
+			return err
+		}
+		// The first item of the first page is the last movement up to the bound. Taking
+		// its balance keeps the two consistent even where statements of a transaction
+		// see different commits, as under READ COMMITTED
+		if query.Offset == 0 && len(page.Items) > 0 {
+			page.Balance = page.Items[0].Balance
+			return nil
+		}
+		page.Balance, err = s.movementRepo.BalanceAt(ctx, id, balanceAt)
+		return err
+	})
+	if err != nil {
+		return errorResponse(err, "Failed to fetch stock movements")
+	}
+	page.BalanceAt = balanceAt
+
+	if page.Items == nil {
+		page.Items = []product.StockMovement{}
+	}
+	page.Limit = query.Limit
+	page.Offset = query.Offset
+
+	return utils.ServiceResponse{
+		Code:    http.StatusOK,
+		Message: "Stock movements fetched successfully",
+		Data:    page,
+	}
+}
+
+func parseTime(value, name string, arrErrors *[]string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		*arrErrors = append(*arrErrors, "Invalid "+name+", must be an RFC 3339 timestamp")
+		return nil
+	}
+	return &t
+}
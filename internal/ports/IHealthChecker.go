@@ -0,0 +1,9 @@
+package ports
+
+import "context"
+
+// IHealthChecker probes one dependency, such as a database connection pool
+type IHealthChecker interface {
+	// Check returns an error when the dependency cannot serve requests
+	Check(ctx context.Context) error
+}
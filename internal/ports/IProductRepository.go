@@ -2,15 +2,21 @@ package ports
 
 import (
 	product "CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 // Repository defines the interface for product operations
 type IProductRepository interface {
-	FindAll() ([]product.Product, error) // Ensure the correct product type
-	FindByID(id uuid.UUID) (product.Product, error)
-	Create(product product.Product) error // Use product.Product here
-	Update(product product.Product) error
-	Delete(id uuid.UUID) error
+	FindAll(ctx context.Context, query product.ProductQuery) (product.ProductPage, error) // Items, Total and HasMore are filled by the repository
+	FindByID(ctx context.Context, id uuid.UUID) (product.Product, error)
+	Create(ctx context.Context, product product.Product) error // Use product.Product here
+	// Update succeeds only while the stored version equals product.Version and returns the stored product with its version incremented
+	Update(ctx context.Context, product product.Product) (product.Product, error)
+	// AdjustStock atomically adds delta to the stock and fails with a conflict if the result would be negative
+	AdjustStock(ctx context.Context, id uuid.UUID, delta int, updatedAt time.Time) (product.Product, error)
+	// Delete removes the product when expectedVersion is 0 or equals the stored version
+	Delete(ctx context.Context, id uuid.UUID, expectedVersion int64) error
 }
@@ -2,8 +2,14 @@ package ports
 
 import (
 	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"context"
 )
 
 type IProfilingRepository interface {
-	Create(profiling models.Profiling) error
+	// CreateMany stores a batch of records in one round trip. When only some of them
+	// are rejected it returns an *errs.PartialError counting those
+	CreateMany(ctx context.Context, records []models.Profiling) error
+	// Summarize aggregates the records matching query per window and route,
+	// ordered as models.SortRouteStats orders them
+	Summarize(ctx context.Context, query models.ProfilingQuery) ([]models.RouteStats, error)
 }
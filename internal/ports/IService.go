@@ -3,16 +3,32 @@ package ports
 import (
 	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
 	"CRUD-Go-Hexa-MongoDB/internal/utils"
+	"context"
 )
 
 type IProductService interface {
-	FindAll() utils.ServiceResponse
-	FindByID(idStr string) utils.ServiceResponse
-	Create(productData map[string]string) utils.ServiceResponse
-	Update(idStr string, productData map[string]string) utils.ServiceResponse
-	Delete(idStr string) utils.ServiceResponse
+	FindAll(ctx context.Context, queryParams map[string]string) utils.ServiceResponse
+	FindByID(ctx context.Context, idStr string) utils.ServiceResponse
+	Create(ctx context.Context, req models.CreateProductRequest) utils.ServiceResponse
+	// expected carries the If-Match precondition, empty skips it
+	Update(ctx context.Context, idStr string, req models.UpdateProductRequest, expected models.ExpectedVersions) utils.ServiceResponse
+	Patch(ctx context.Context, idStr string, req models.PatchProductRequest, expected models.ExpectedVersions) utils.ServiceResponse
+	Delete(ctx context.Context, idStr string, expected models.ExpectedVersions) utils.ServiceResponse
+	AdjustStock(ctx context.Context, idStr string, req models.AdjustStockRequest) utils.ServiceResponse
+	ListMovements(ctx context.Context, idStr string, queryParams map[string]string) utils.ServiceResponse
 }
 
 type IProfilingService interface {
-	Log(profiling models.Profiling) error
+	Log(ctx context.Context, profiling models.Profiling) error
+	// Flush writes out any buffered records, it is called once on shutdown
+	Flush(ctx context.Context) error
+	Stats() models.ProfilingStats
+	Summarize(ctx context.Context, queryParams map[string]string) utils.ServiceResponse
+}
+
+type IHealthService interface {
+	// Liveness reports that the process is running, without looking at dependencies
+	Liveness(ctx context.Context) utils.ServiceResponse
+	// Readiness checks every dependency and answers 503 when a critical one is down
+	Readiness(ctx context.Context) utils.ServiceResponse
 }
@@ -0,0 +1,19 @@
+package ports
+
+import (
+	"CRUD-Go-Hexa-MongoDB/internal/domain/models"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IStockMovementRepository interface {
+	// Append records the movement with its CreatedAt raised to that of the product's previous
+	// movement, if later, so that the time filters below select a run of the ledger order
+	// even when the clocks of the instances writing it disagree
+	Append(ctx context.Context, movement models.StockMovement) error
+	List(ctx context.Context, query models.MovementQuery) (models.MovementPage, error) // Items, Total and HasMore are filled by the repository
+	// BalanceAt returns the balance of the last movement recorded at or before at, or 0 if there is none
+	BalanceAt(ctx context.Context, productID uuid.UUID, at time.Time) (int, error)
+}
@@ -0,0 +1,9 @@
+package ports
+
+import "context"
+
+// ITransactor runs fn in a single transaction. Repositories of the same adapter called with the
+// context handed to fn take part in it; the transaction is rolled back when fn returns an error
+type ITransactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"context"
+	"log/slog"
+)
+
+// contextHandler adds the request ID carried by the context to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+// ContextHandler wraps next so that log calls given a request context, such as
+// InfoContext, carry a request_id attribute
+func ContextHandler(next slog.Handler) slog.Handler {
+	return contextHandler{Handler: next}
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -4,4 +4,6 @@ type ServiceResponse struct {
 	Code    int
 	Message string
 	Data    interface{}
+	// RequestID is filled in on error responses so callers can quote it when reporting a problem
+	RequestID string `json:",omitempty"`
 }
@@ -0,0 +1,37 @@
+package utils
+
+import "github.com/gofiber/fiber/v2"
+
+// UnmatchedRoute labels requests no route matched, so profiling, metrics and traces
+// aggregate them under one route instead of one per path
+const UnmatchedRoute = "<unmatched>"
+
+// HandleError runs the application's error handler on an error returned by the rest
+// of the chain, so middleware observing the response sees the status that is sent
+func HandleError(ctx *fiber.Ctx, err error) {
+	if err == nil {
+		return
+	}
+	if err := ctx.App().ErrorHandler(ctx, err); err != nil {
+		_ = ctx.SendStatus(fiber.StatusInternalServerError)
+	}
+}
+
+// MatchedRoute is the route that handled the request, or nil when only middleware
+// matched. self is the calling middleware's route, read before ctx.Next: when no
+// route matched, the current route is still that one
+func MatchedRoute(ctx *fiber.Ctx, self *fiber.Route) *fiber.Route {
+	if route := ctx.Route(); route != self {
+		return route
+	}
+	return nil
+}
+
+// RouteTemplate is the template of the route that handled the request, or
+// UnmatchedRoute, see MatchedRoute
+func RouteTemplate(ctx *fiber.Ctx, self *fiber.Route) string {
+	if route := MatchedRoute(ctx, self); route != nil {
+		return route.Path
+	}
+	return UnmatchedRoute
+}
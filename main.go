@@ -2,10 +2,75 @@ package main
 
 import (
 	"CRUD-Go-Hexa-MongoDB/internal/app"
+	"CRUD-Go-Hexa-MongoDB/pkg/config"
+	"context"
+	"errors"
+	"flag"
 	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
-	app := app.Setup()
-	log.Fatal(app.Listen(":3000"))
+	cfg, opts, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if opts.PrintConfig {
+		if err := config.Print(os.Stdout, cfg); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if len(opts.Args) > 0 {
+		if opts.Args[0] != "migrate" {
+			log.Fatalf("unknown command %q, want migrate", opts.Args[0])
+		}
+		if err := app.Migrate(cfg, opts.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Route the standard log package through the same structured output
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	slog.SetDefault(logger)
+
+	app := app.New(ctx, cfg, logger)
+
+	listenErr := make(chan error, 1)
+	go func() {
+		if cfg.TLSCertFile != "" {
+			listenErr <- app.ListenTLS(cfg.ServerAddr, cfg.TLSCertFile, cfg.TLSKeyFile)
+			return
+		}
+		listenErr <- app.Listen(cfg.ServerAddr)
+	}()
+
+	var serveErr error
+	select {
+	case serveErr = <-listenErr:
+	case <-ctx.Done():
+		logger.Info("shutting down")
+	}
+	// A second signal kills the process right away
+	stop()
+
+	// Release the databases and flush what was recorded even when the server failed
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := errors.Join(serveErr, app.Close(shutdownCtx)); err != nil {
+		log.Fatal(err)
+	}
 }
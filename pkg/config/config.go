@@ -2,35 +2,298 @@
 package config
 
 import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
 	"log"
+	"log/slog"
+	"net"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Repository backends selectable per port
+const (
+	BackendPostgres = "postgres"
+	BackendMongo    = "mongo"
+	BackendSQLite   = "sqlite"
+	// BackendMemory keeps everything in process memory, no database required
+	BackendMemory = "memory"
+	// BackendDatabase is the former REPOSITORY_BACKEND value for products in PostgreSQL
+	// and profiling in MongoDB, still accepted and the same as leaving it unset
+	BackendDatabase = "database"
+)
+
+// Startup policies for pending PostgreSQL migrations
+const (
+	// MigrateAuto applies pending migrations on startup
+	MigrateAuto = "auto"
+	// MigrateCheck refuses to start while migrations are pending
+	MigrateCheck = "check"
+	// MigrateOff leaves the schema alone
+	MigrateOff = "off"
+)
+
 type Config struct {
-	MongoURI       string
-	DBName         string
-	PostgresUser   string
-	PostgresPass   string
-	PostgresHost   string
-	PostgresPort   string
-	PostgresDBName string
+	// ServerAddr is the host:port to listen on
+	ServerAddr string
+	// HTTP server timeouts, zero leaves connections unbounded
+	ServerReadTimeout  time.Duration
+	ServerWriteTimeout time.Duration
+	ServerIdleTimeout  time.Duration
+	// RequestTimeout bounds the database work done on behalf of a single request
+	RequestTimeout time.Duration
+	// ShutdownTimeout bounds how long in-flight requests may take to finish on shutdown
+	ShutdownTimeout time.Duration
+	// The server speaks HTTPS when both TLS files are set
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// Credentials, see SecretSource for where they can come from. The mongo product
+	// store runs its writes in transactions, so MongoURI must then point at a replica
+	// set or sharded cluster; profiling alone works with a standalone server
+	MongoURI Secret
+	DBName   string
+	// MongoMaxPoolSize zero keeps the driver default
+	MongoMaxPoolSize int
+	PostgresUser     string
+	PostgresPass     Secret
+	PostgresHost     string
+	PostgresPort     int
+	PostgresDBName   string
+	// PostgresSSLMode is "disable", "require", "verify-ca" or "verify-full"
+	PostgresSSLMode string
+	// PostgreSQL connection pool, zero values keep the database/sql defaults
+	PostgresMaxOpenConns    int
+	PostgresMaxIdleConns    int
+	PostgresConnMaxLifetime time.Duration
+	// PostgresMigrate is one of the Migrate constants
+	PostgresMigrate string
+	SQLitePath      string
+	// ProfilingTTL expires profiling records after this long, zero keeps them forever
+	ProfilingTTL time.Duration
+	// Profiling write pipeline, zero values fall back to the service defaults
+	ProfilingQueueSize     int
+	ProfilingBatchSize     int
+	ProfilingFlushInterval time.Duration
+	// ProfilingOverflow is "drop" or "block", see services.OverflowPolicy
+	ProfilingOverflow string
+	// Profiling sampling, see services.SamplingRules
+	ProfilingSampleRate    float64
+	ProfilingSlowThreshold time.Duration
+	ProfilingKeepErrors    bool
+	ProfilingInclude       []string
+	ProfilingExclude       []string
+	// Startup connection retries: attempts in total, with a delay starting at
+	// ConnectBackoff and doubling up to ConnectBackoffMax. ConnectTimeout bounds each attempt
+	ConnectAttempts   int
+	ConnectBackoff    time.Duration
+	ConnectBackoffMax time.Duration
+	ConnectTimeout    time.Duration
+	// ProfilingOptional starts without profiling when its store is down, and keeps
+	// retrying in the background until it comes back
+	ProfilingOptional bool
+	// Adapter names for each port, see the Backend constants
+	ProductRepository   string
+	ProfilingRepository string
+	// TracingExporter is "none", "otlp" or "file"; the OTLP endpoint comes from the
+	// standard OTEL_EXPORTER_OTLP_* variables
+	TracingExporter   string
+	TracingFile       string
+	TracingSampleRate float64
+	// ProfilingAdmin serves the profiling summary at /admin/profiling. It has no
+	// authentication, so only enable it where the port is not publicly reachable
+	ProfilingAdmin bool
+	// Readiness checks, zero values fall back to the service defaults
+	HealthTimeout       time.Duration
+	HealthSlowThreshold time.Duration
+
+	// repositoryBackend picks one backend for every port unless a port overrides it
+	repositoryBackend string
 }
 
-func LoadConfig() *Config {
+// Default returns the configuration used for anything left unset
+func Default() *Config {
+	return &Config{
+		ServerAddr:         ":3000",
+		ServerReadTimeout:  15 * time.Second,
+		ServerWriteTimeout: 30 * time.Second,
+		ServerIdleTimeout:  2 * time.Minute,
+		RequestTimeout:     10 * time.Second,
+		ShutdownTimeout:    15 * time.Second,
+		PostgresPort:       5432,
+		PostgresSSLMode:    "disable",
+		PostgresMigrate:    MigrateAuto,
+		SQLitePath:         "hexa.db",
+		ProfilingOverflow:  "drop",
+		// Keep every record unless told otherwise
+		ProfilingSampleRate: 1,
+		ProfilingKeepErrors: true,
+		ConnectAttempts:     5,
+		ConnectBackoff:      time.Second,
+		ConnectBackoffMax:   30 * time.Second,
+		ConnectTimeout:      5 * time.Second,
+		TracingExporter:     "none",
+		TracingFile:         "traces.json",
+		TracingSampleRate:   1,
+	}
+}
+
+// Options are the command line flags that are not settings
+type Options struct {
+	// File is the config file, from --config or CONFIG_FILE
+	File string
+	// SecretsDir holds one file per secret, from --secrets-dir or SECRETS_DIR
+	SecretsDir string
+	// PrintConfig asks for the configuration to be printed instead of served
+	PrintConfig bool
+	// Args are what is left after the flags, such as a subcommand
+	Args []string
+}
+
+// Load builds the configuration in layers, each overriding the ones before it:
+// the defaults, the config file, the .env file, the environment and finally the
+// flags in args. Every setting is an environment variable such as POSTGRES_HOST,
+// a top-level key such as postgres_host in the YAML or TOML config file, and a
+// flag such as --postgres-host. Secrets are looked up alongside the environment,
+// see EnvSecrets and DirSecrets. All the problems found are reported together
+func Load(args []string) (*Config, Options, error) {
+	cfg := Default()
+	settings := cfg.settings()
+
+	var opts Options
+	flags := flag.NewFlagSet("hexa", flag.ContinueOnError)
+	flags.StringVar(&opts.File, "config", os.Getenv("CONFIG_FILE"), "YAML or TOML config `file`")
+	flags.StringVar(&opts.SecretsDir, "secrets-dir", os.Getenv("SECRETS_DIR"), "`directory` of secret files such as postgres_password")
+	flags.BoolVar(&opts.PrintConfig, "print-config", false, "print the configuration with secrets redacted and exit")
+	// Flags are applied last, once the other layers are in place
+	var flagged []assignment
+	for _, s := range settings {
+		flags.Var(deferredFlag{setting: s, assignments: &flagged}, s.flagName(), s.usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, opts, err
+	}
+	opts.Args = flags.Args()
+
+	var problems []error
+	set := func(s setting, value, source string) {
+		if err := s.value.Set(value); err != nil {
+			problems = append(problems, fmt.Errorf("%s from %s: %w", s.key, source, err))
+		}
+	}
+
+	if opts.File != "" {
+		values, err := readFile(opts.File)
+		if err != nil {
+			problems = append(problems, err)
+		}
+		for _, entry := range values {
+			s, ok := lookup(settings, entry.key)
+			if !ok {
+				problems = append(problems, fmt.Errorf("unknown setting %q in %s", entry.key, opts.File))
+				continue
+			}
+			set(s, entry.value, opts.File)
+		}
+	}
+
+	// The .env file is optional when everything is already in the environment,
+	// and never overrides what is
 	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		problems = append(problems, fmt.Errorf("load .env: %w", err))
+	}
+	secrets := SecretSources{EnvSecrets{}, DirSecrets(opts.SecretsDir)}
+	defaults := Default().settings()
+	for i, s := range settings {
+		if _, ok := s.value.(*secretValue); ok {
+			value, found, err := secrets.Lookup(s.key)
+			if err != nil {
+				problems = append(problems, err)
+			} else if found {
+				set(s, value, "secrets")
+			}
+			continue
+		}
+		// A variable set to nothing still overrides the file: it clears a string and
+		// resets a setting that has no empty value, such as a port, to its default
+		if value, ok := os.LookupEnv(s.key); ok {
+			if value == "" && !s.acceptsEmpty() {
+				value = defaults[i].value.String()
+			}
+			set(s, value, "environment")
+		}
 	}
 
-	return &Config{
-		MongoURI:       os.Getenv("MONGO_URI"),
-		DBName:         os.Getenv("DB_NAME"),
-		PostgresUser:   os.Getenv("POSTGRES_USER"),
-		PostgresPass:   os.Getenv("POSTGRES_PASSWORD"),
-		PostgresHost:   os.Getenv("POSTGRES_HOST"),
-		PostgresPort:   os.Getenv("POSTGRES_PORT"),
-		PostgresDBName: os.Getenv("POSTGRES_DB"),
+	for _, a := range flagged {
+		set(a.setting, a.value, "--"+a.setting.flagName())
+	}
+
+	backend := cfg.repositoryBackend
+	if backend == BackendDatabase {
+		backend = ""
+	}
+	if cfg.ProductRepository == "" {
+		cfg.ProductRepository = cmp.Or(backend, BackendPostgres)
+	}
+	if cfg.ProfilingRepository == "" {
+		cfg.ProfilingRepository = cmp.Or(backend, BackendMongo)
+	}
+
+	problems = append(problems, cfg.Validate())
+	if err := errors.Join(problems...); err != nil {
+		return nil, opts, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, opts, nil
+}
+
+// PostgresURL is the connection string for PostgreSQL, every part escaped so
+// credentials may hold any character
+func (c *Config) PostgresURL() Secret {
+	query := url.Values{"sslmode": {cmp.Or(c.PostgresSSLMode, "disable")}}
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(c.PostgresUser, c.PostgresPass.Reveal()),
+		Host:   c.PostgresHost,
+		Path:   "/" + c.PostgresDBName,
+	}
+	switch {
+	case strings.HasPrefix(c.PostgresHost, "/"):
+		// A Unix socket directory cannot be the URL host
+		dsn.Host = ""
+		query.Set("host", c.PostgresHost)
+		if c.PostgresPort != 0 {
+			query.Set("port", strconv.Itoa(c.PostgresPort))
+		}
+	case c.PostgresPort != 0:
+		dsn.Host = net.JoinHostPort(c.PostgresHost, strconv.Itoa(c.PostgresPort))
+	}
+	dsn.RawQuery = query.Encode()
+	return Secret(dsn.String())
+}
+
+// LogValue logs the settings as Print shows them, with secrets redacted
+func (c *Config) LogValue() slog.Value {
+	var attrs []slog.Attr
+	for _, s := range c.settings() {
+		attrs = append(attrs, slog.String(s.fileKey(), s.display()))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// LoadConfig loads the configuration without flags, exiting on any problem
+func LoadConfig() *Config {
+	cfg, _, err := Load(nil)
+	if err != nil {
+		log.Fatal(err)
 	}
+	return cfg
 }
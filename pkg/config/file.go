@@ -0,0 +1,92 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// entry is one setting read from a config file
+type entry struct {
+	key   string
+	value string
+}
+
+// readFile reads the settings of a YAML or TOML file, picked by its extension.
+// Lists become comma separated values, like in the environment
+func readFile(path string) ([]entry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	values := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &values)
+	case ".toml":
+		err = toml.Unmarshal(raw, &values)
+	default:
+		return nil, fmt.Errorf("config file %s is not .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	var entries []entry
+	var problems []error
+	for key, value := range values {
+		switch value := value.(type) {
+		case map[string]any:
+			problems = append(problems, fmt.Errorf("setting %q in %s is a table, settings are top-level keys", key, path))
+		case []any:
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = fmt.Sprint(item)
+			}
+			entries = append(entries, entry{key, strings.Join(items, ",")})
+		case nil:
+			entries = append(entries, entry{key, ""})
+		default:
+			entries = append(entries, entry{key, fmt.Sprint(value)})
+		}
+	}
+	// Report problems in a stable order
+	slices.SortFunc(entries, func(a, b entry) int { return strings.Compare(a.key, b.key) })
+	return entries, errors.Join(problems...)
+}
+
+// Print writes cfg as a YAML config file, with secrets redacted
+func Print(w io.Writer, cfg *Config) error {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range cfg.settings() {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: s.fileKey()}
+		var value *yaml.Node
+		switch v := s.value.(type) {
+		case *listValue:
+			value = &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+			for _, item := range *v {
+				value.Content = append(value.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: item})
+			}
+		case *stringValue, *secretValue:
+			value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s.display()}
+		default:
+			value = &yaml.Node{Kind: yaml.ScalarNode, Value: s.display()}
+		}
+		doc.Content = append(doc.Content, key, value)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// redacted replaces secrets wherever they are printed
+const redacted = "REDACTED"
+
+// Secret is a credential that stays hidden when formatted, logged or marshalled.
+// Reveal is the only way to read it
+type Secret string
+
+func (s Secret) Reveal() string { return string(s) }
+
+// String hides the secret, keeping only whether it is set
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string { return `config.Secret("` + s.String() + `")` }
+
+func (s Secret) LogValue() slog.Value { return slog.StringValue(s.String()) }
+
+func (s Secret) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+// redactURL hides the password of a connection string, keeping the rest readable
+func redactURL(s Secret) string {
+	parsed, err := url.Parse(s.Reveal())
+	if err != nil || parsed.Scheme == "" {
+		return s.String()
+	}
+	if _, ok := parsed.User.Password(); ok {
+		parsed.User = url.UserPassword(parsed.User.Username(), redacted)
+	}
+	return parsed.String()
+}
+
+// SecretSource looks up a secret by its setting key, such as POSTGRES_PASSWORD
+type SecretSource interface {
+	Lookup(key string) (value string, found bool, err error)
+}
+
+// EnvSecrets reads secrets from the environment, either from the variable itself
+// or from the file named by the same variable with a _FILE suffix
+type EnvSecrets struct{}
+
+func (EnvSecrets) Lookup(key string) (string, bool, error) {
+	value, path := os.Getenv(key), os.Getenv(key+"_FILE")
+	switch {
+	case value != "" && path != "":
+		return "", false, fmt.Errorf("%s and %s_FILE are both set, use one", key, key)
+	case path != "":
+		value, err := readSecret(path)
+		if err != nil {
+			return "", false, fmt.Errorf("%s_FILE: %w", key, err)
+		}
+		return value, true, nil
+	}
+	return value, value != "", nil
+}
+
+// DirSecrets reads secrets from files named after the key in lower case, such as
+// postgres_password, the way Docker and Kubernetes mount them. Missing files are unset
+type DirSecrets string
+
+func (d DirSecrets) Lookup(key string) (string, bool, error) {
+	if d == "" {
+		return "", false, nil
+	}
+	value, err := readSecret(filepath.Join(string(d), strings.ToLower(key)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("%s: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// SecretSources looks a secret up in each source in turn, the first one that has it wins
+type SecretSources []SecretSource
+
+func (sources SecretSources) Lookup(key string) (string, bool, error) {
+	for _, source := range sources {
+		value, found, err := source.Lookup(key)
+		if err != nil || found {
+			return value, found, err
+		}
+	}
+	return "", false, nil
+}
+
+// readSecret reads a secret file, without the line break editors leave at the end
+func readSecret(path string) (string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(raw), "\r\n"), nil
+}
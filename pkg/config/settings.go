@@ -0,0 +1,223 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting binds one Config field to its name. The key is the environment
+// variable; config files use it in lower case and flags in lower case with dashes
+type setting struct {
+	key   string
+	usage string
+	value flag.Value
+	// redact shows a secret when the configuration is printed, hiding it entirely when nil
+	redact func(Secret) string
+}
+
+// assignment is a value given to a setting on the command line
+type assignment struct {
+	setting setting
+	value   string
+}
+
+// deferredFlag records the values its flag is given instead of setting them, so
+// they can be applied over the other layers. Like the setting's value, it is a
+// boolean flag that needs no value when the setting is boolean
+type deferredFlag struct {
+	setting     setting
+	assignments *[]assignment
+}
+
+func (f deferredFlag) Set(value string) error {
+	*f.assignments = append(*f.assignments, assignment{f.setting, value})
+	return nil
+}
+
+func (f deferredFlag) String() string { return "" }
+
+func (f deferredFlag) IsBoolFlag() bool {
+	boolFlag, ok := f.setting.value.(interface{ IsBoolFlag() bool })
+	return ok && boolFlag.IsBoolFlag()
+}
+
+// acceptsEmpty reports whether an empty value is valid for the setting
+func (s setting) acceptsEmpty() bool {
+	switch s.value.(type) {
+	case *stringValue, *listValue:
+		return true
+	}
+	return false
+}
+
+// display is the value as printed or logged, with secrets redacted
+func (s setting) display() string {
+	if secret, ok := s.value.(*secretValue); ok && s.redact != nil {
+		return s.redact(Secret(*secret))
+	}
+	return s.value.String()
+}
+
+func (s setting) fileKey() string {
+	return strings.ToLower(s.key)
+}
+
+func (s setting) flagName() string {
+	return strings.ReplaceAll(s.fileKey(), "_", "-")
+}
+
+// lookup finds a setting by its environment, file or flag name
+func lookup(settings []setting, name string) (setting, bool) {
+	key := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+	for _, s := range settings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// settings lists every setting, bound to the fields of c
+func (c *Config) settings() []setting {
+	return []setting{
+		{key: "SERVER_ADDR", usage: "host:port to listen on", value: (*stringValue)(&c.ServerAddr)},
+		{key: "SERVER_READ_TIMEOUT", usage: "longest time to read a request", value: (*durationValue)(&c.ServerReadTimeout)},
+		{key: "SERVER_WRITE_TIMEOUT", usage: "longest time to write a response", value: (*durationValue)(&c.ServerWriteTimeout)},
+		{key: "SERVER_IDLE_TIMEOUT", usage: "longest time to keep an idle connection", value: (*durationValue)(&c.ServerIdleTimeout)},
+		{key: "REQUEST_TIMEOUT", usage: "longest database work for one request", value: (*durationValue)(&c.RequestTimeout)},
+		{key: "SHUTDOWN_TIMEOUT", usage: "longest wait for in-flight requests on shutdown", value: (*durationValue)(&c.ShutdownTimeout)},
+		{key: "TLS_CERT_FILE", usage: "certificate to serve HTTPS with", value: (*stringValue)(&c.TLSCertFile)},
+		{key: "TLS_KEY_FILE", usage: "private key of the certificate", value: (*stringValue)(&c.TLSKeyFile)},
+		{key: "REPOSITORY_BACKEND", usage: "backend for every port that does not name its own", value: (*stringValue)(&c.repositoryBackend)},
+		{key: "PRODUCT_REPOSITORY", usage: "backend for products: postgres, mongo, sqlite or memory", value: (*stringValue)(&c.ProductRepository)},
+		{key: "PROFILING_REPOSITORY", usage: "backend for profiling: postgres, mongo, sqlite or memory", value: (*stringValue)(&c.ProfilingRepository)},
+		{key: "MONGO_URI", usage: "MongoDB connection string, a replica set or sharded cluster for products", value: (*secretValue)(&c.MongoURI), redact: redactURL},
+		{key: "DB_NAME", usage: "MongoDB database", value: (*stringValue)(&c.DBName)},
+		{key: "MONGO_MAX_POOL_SIZE", usage: "most MongoDB connections, 0 for the driver default", value: (*intValue)(&c.MongoMaxPoolSize)},
+		{key: "POSTGRES_HOST", usage: "PostgreSQL host", value: (*stringValue)(&c.PostgresHost)},
+		{key: "POSTGRES_PORT", usage: "PostgreSQL port", value: (*intValue)(&c.PostgresPort)},
+		{key: "POSTGRES_USER", usage: "PostgreSQL user", value: (*stringValue)(&c.PostgresUser)},
+		{key: "POSTGRES_PASSWORD", usage: "PostgreSQL password", value: (*secretValue)(&c.PostgresPass)},
+		{key: "POSTGRES_DB", usage: "PostgreSQL database", value: (*stringValue)(&c.PostgresDBName)},
+		{key: "POSTGRES_SSLMODE", usage: "disable, require, verify-ca or verify-full", value: (*stringValue)(&c.PostgresSSLMode)},
+		{key: "POSTGRES_MAX_OPEN_CONNS", usage: "most open PostgreSQL connections, 0 for no limit", value: (*intValue)(&c.PostgresMaxOpenConns)},
+		{key: "POSTGRES_MAX_IDLE_CONNS", usage: "most idle PostgreSQL connections, 0 for the default", value: (*intValue)(&c.PostgresMaxIdleConns)},
+		{key: "POSTGRES_CONN_MAX_LIFETIME", usage: "longest a PostgreSQL connection is reused, 0 for ever", value: (*durationValue)(&c.PostgresConnMaxLifetime)},
+		{key: "POSTGRES_MIGRATE", usage: "pending migrations on startup: auto, check or off", value: (*stringValue)(&c.PostgresMigrate)},
+		{key: "SQLITE_PATH", usage: "SQLite database file", value: (*stringValue)(&c.SQLitePath)},
+		{key: "CONNECT_ATTEMPTS", usage: "tries to reach each database on startup", value: (*intValue)(&c.ConnectAttempts)},
+		{key: "CONNECT_BACKOFF", usage: "first delay between connection tries", value: (*durationValue)(&c.ConnectBackoff)},
+		{key: "CONNECT_BACKOFF_MAX", usage: "longest delay between connection tries", value: (*durationValue)(&c.ConnectBackoffMax)},
+		{key: "CONNECT_TIMEOUT", usage: "longest single connection try", value: (*durationValue)(&c.ConnectTimeout)},
+		{key: "PROFILING_OPTIONAL", usage: "start without profiling when its store is down", value: (*boolValue)(&c.ProfilingOptional)},
+		{key: "PROFILING_TTL", usage: "expire profiling records after this long, 0 keeps them", value: (*durationValue)(&c.ProfilingTTL)},
+		{key: "PROFILING_QUEUE_SIZE", usage: "profiling records waiting to be written", value: (*intValue)(&c.ProfilingQueueSize)},
+		{key: "PROFILING_BATCH_SIZE", usage: "profiling records written at once", value: (*intValue)(&c.ProfilingBatchSize)},
+		{key: "PROFILING_FLUSH_INTERVAL", usage: "longest wait before writing a partial batch", value: (*durationValue)(&c.ProfilingFlushInterval)},
+		{key: "PROFILING_OVERFLOW", usage: "drop or block when the profiling queue is full", value: (*stringValue)(&c.ProfilingOverflow)},
+		{key: "PROFILING_SAMPLE_RATE", usage: "share of requests profiled, from 0 to 1", value: (*floatValue)(&c.ProfilingSampleRate)},
+		{key: "PROFILING_SLOW_THRESHOLD", usage: "always profile requests slower than this", value: (*durationValue)(&c.ProfilingSlowThreshold)},
+		{key: "PROFILING_KEEP_ERRORS", usage: "always profile failed requests", value: (*boolValue)(&c.ProfilingKeepErrors)},
+		{key: "PROFILING_INCLUDE", usage: "comma separated routes to profile", value: (*listValue)(&c.ProfilingInclude)},
+		{key: "PROFILING_EXCLUDE", usage: "comma separated routes never to profile", value: (*listValue)(&c.ProfilingExclude)},
+		{key: "PROFILING_ADMIN", usage: "serve the unauthenticated profiling summary at /admin/profiling", value: (*boolValue)(&c.ProfilingAdmin)},
+		{key: "TRACING_EXPORTER", usage: "none, otlp or file", value: (*stringValue)(&c.TracingExporter)},
+		{key: "TRACING_FILE", usage: "file the file exporter writes to", value: (*stringValue)(&c.TracingFile)},
+		{key: "TRACING_SAMPLE_RATE", usage: "share of traces started here that are kept, from 0 to 1", value: (*floatValue)(&c.TracingSampleRate)},
+		{key: "HEALTH_TIMEOUT", usage: "longest readiness check of one dependency", value: (*durationValue)(&c.HealthTimeout)},
+		{key: "HEALTH_SLOW_THRESHOLD", usage: "dependencies slower than this are degraded", value: (*durationValue)(&c.HealthSlowThreshold)},
+	}
+}
+
+type stringValue string
+
+func (v *stringValue) Set(s string) error {
+	*v = stringValue(s)
+	return nil
+}
+
+func (v *stringValue) String() string { return string(*v) }
+
+// secretValue prints redacted, including in flag usage
+type secretValue Secret
+
+func (v *secretValue) Set(s string) error {
+	*v = secretValue(s)
+	return nil
+}
+
+func (v *secretValue) String() string { return Secret(*v).String() }
+
+type intValue int
+
+func (v *intValue) Set(s string) error {
+	parsed, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("%q is not a whole number", s)
+	}
+	*v = intValue(parsed)
+	return nil
+}
+
+func (v *intValue) String() string { return strconv.Itoa(int(*v)) }
+
+type floatValue float64
+
+func (v *floatValue) Set(s string) error {
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return fmt.Errorf("%q is not a number", s)
+	}
+	*v = floatValue(parsed)
+	return nil
+}
+
+func (v *floatValue) String() string { return strconv.FormatFloat(float64(*v), 'g', -1, 64) }
+
+type boolValue bool
+
+func (v *boolValue) Set(s string) error {
+	parsed, err := strconv.ParseBool(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("%q is not true or false", s)
+	}
+	*v = boolValue(parsed)
+	return nil
+}
+
+func (v *boolValue) String() string { return strconv.FormatBool(bool(*v)) }
+
+// IsBoolFlag lets a flag such as --profiling-optional stand for --profiling-optional=true
+func (v *boolValue) IsBoolFlag() bool { return true }
+
+type durationValue time.Duration
+
+func (v *durationValue) Set(s string) error {
+	parsed, err := time.ParseDuration(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("%q is not a duration such as 500ms or 5s", s)
+	}
+	*v = durationValue(parsed)
+	return nil
+}
+
+func (v *durationValue) String() string { return time.Duration(*v).String() }
+
+// listValue is comma separated, ignoring blank entries
+type listValue []string
+
+func (v *listValue) Set(s string) error {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*v = list
+	return nil
+}
+
+func (v *listValue) String() string { return strings.Join(*v, ",") }
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+)
+
+var (
+	backends         = []string{BackendPostgres, BackendMongo, BackendSQLite, BackendMemory}
+	migratePolicies  = []string{MigrateAuto, MigrateCheck, MigrateOff}
+	postgresSSLModes = []string{"disable", "require", "verify-ca", "verify-full"}
+	overflowPolicies = []string{"drop", "block"}
+	tracingExporters = []string{"none", "otlp", "file"}
+)
+
+// maxProfilingTTL is the longest retention a MongoDB TTL index can hold
+const maxProfilingTTL = math.MaxInt32 * time.Second
+
+// Validate reports every invalid setting at once. Connection settings are only
+// required for the backends in use
+func (c *Config) Validate() error {
+	var problems []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			problems = append(problems, fmt.Errorf(format, args...))
+		}
+	}
+	oneOf := func(key, value string, allowed []string) {
+		check(slices.Contains(allowed, value), "%s %q is not one of %v", key, value, allowed)
+	}
+	notNegative := func(key string, value time.Duration) {
+		check(value >= 0, "%s %s must not be negative", key, value)
+	}
+	rate := func(key string, value float64) {
+		check(value >= 0 && value <= 1, "%s %v must be between 0 and 1", key, value)
+	}
+
+	_, port, err := net.SplitHostPort(c.ServerAddr)
+	if err == nil {
+		_, err = strconv.ParseUint(port, 10, 16)
+	}
+	check(err == nil, "SERVER_ADDR %q is not a host:port address", c.ServerAddr)
+	notNegative("SERVER_READ_TIMEOUT", c.ServerReadTimeout)
+	notNegative("SERVER_WRITE_TIMEOUT", c.ServerWriteTimeout)
+	notNegative("SERVER_IDLE_TIMEOUT", c.ServerIdleTimeout)
+	check(c.RequestTimeout > 0, "REQUEST_TIMEOUT %s must be positive", c.RequestTimeout)
+	check(c.ShutdownTimeout > 0, "SHUTDOWN_TIMEOUT %s must be positive", c.ShutdownTimeout)
+	check((c.TLSCertFile == "") == (c.TLSKeyFile == ""), "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	fileExists := func(key, path string) {
+		if path != "" {
+			_, err := os.Stat(path)
+			check(err == nil, "%s: %v", key, err)
+		}
+	}
+	fileExists("TLS_CERT_FILE", c.TLSCertFile)
+	fileExists("TLS_KEY_FILE", c.TLSKeyFile)
+
+	oneOf("PRODUCT_REPOSITORY", c.ProductRepository, backends)
+	oneOf("PROFILING_REPOSITORY", c.ProfilingRepository, backends)
+	uses := func(backend string) bool {
+		return c.ProductRepository == backend || c.ProfilingRepository == backend
+	}
+	if uses(BackendPostgres) {
+		check(c.PostgresHost != "", "POSTGRES_HOST is required for the postgres backend")
+		check(c.PostgresUser != "", "POSTGRES_USER is required for the postgres backend")
+		check(c.PostgresDBName != "", "POSTGRES_DB is required for the postgres backend")
+		check(c.PostgresPort > 0 && c.PostgresPort <= 65535, "POSTGRES_PORT %d is not a port number", c.PostgresPort)
+	}
+	if uses(BackendMongo) {
+		check(c.MongoURI != "", "MONGO_URI is required for the mongo backend")
+		check(c.DBName != "", "DB_NAME is required for the mongo backend")
+	}
+	if uses(BackendSQLite) {
+		check(c.SQLitePath != "", "SQLITE_PATH is required for the sqlite backend")
+	}
+	check(c.MongoMaxPoolSize >= 0, "MONGO_MAX_POOL_SIZE %d must not be negative", c.MongoMaxPoolSize)
+	oneOf("POSTGRES_SSLMODE", c.PostgresSSLMode, postgresSSLModes)
+	check(c.PostgresMaxOpenConns >= 0, "POSTGRES_MAX_OPEN_CONNS %d must not be negative", c.PostgresMaxOpenConns)
+	check(c.PostgresMaxIdleConns >= 0, "POSTGRES_MAX_IDLE_CONNS %d must not be negative", c.PostgresMaxIdleConns)
+	notNegative("POSTGRES_CONN_MAX_LIFETIME", c.PostgresConnMaxLifetime)
+	oneOf("POSTGRES_MIGRATE", c.PostgresMigrate, migratePolicies)
+
+	check(c.ConnectAttempts > 0, "CONNECT_ATTEMPTS %d must be at least 1", c.ConnectAttempts)
+	check(c.ConnectBackoff > 0, "CONNECT_BACKOFF %s must be positive", c.ConnectBackoff)
+	notNegative("CONNECT_BACKOFF_MAX", c.ConnectBackoffMax)
+	notNegative("CONNECT_TIMEOUT", c.ConnectTimeout)
+
+	// MongoDB takes the TTL in whole seconds as a 32-bit integer, and 0 would expire everything
+	if c.ProfilingTTL != 0 {
+		check(c.ProfilingTTL >= time.Second, "PROFILING_TTL %s must be 0 or at least 1s", c.ProfilingTTL)
+		check(c.ProfilingTTL%time.Second == 0, "PROFILING_TTL %s must be a whole number of seconds", c.ProfilingTTL)
+		check(c.ProfilingTTL <= maxProfilingTTL, "PROFILING_TTL %s must be at most %s", c.ProfilingTTL, maxProfilingTTL)
+	}
+	check(c.ProfilingQueueSize >= 0, "PROFILING_QUEUE_SIZE %d must not be negative", c.ProfilingQueueSize)
+	check(c.ProfilingBatchSize >= 0, "PROFILING_BATCH_SIZE %d must not be negative", c.ProfilingBatchSize)
+	notNegative("PROFILING_FLUSH_INTERVAL", c.ProfilingFlushInterval)
+	oneOf("PROFILING_OVERFLOW", c.ProfilingOverflow, overflowPolicies)
+	rate("PROFILING_SAMPLE_RATE", c.ProfilingSampleRate)
+	notNegative("PROFILING_SLOW_THRESHOLD", c.ProfilingSlowThreshold)
+
+	oneOf("TRACING_EXPORTER", c.TracingExporter, tracingExporters)
+	check(c.TracingExporter != "file" || c.TracingFile != "", "TRACING_FILE is required for the file exporter")
+	rate("TRACING_SAMPLE_RATE", c.TracingSampleRate)
+
+	notNegative("HEALTH_TIMEOUT", c.HealthTimeout)
+	notNegative("HEALTH_SLOW_THRESHOLD", c.HealthSlowThreshold)
+	return errors.Join(problems...)
+}